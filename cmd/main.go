@@ -2,81 +2,247 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/auth"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/cache"
 	"github.com/AndreySirin/-Effective-Mobile-/internal/config"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/lifecycle"
 	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/metrics"
 	"github.com/AndreySirin/-Effective-Mobile-/internal/server"
 	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tracing"
 	migrate "github.com/rubenv/sql-migrate"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
-	"sync"
+	"syscall"
 )
 
+const usage = `usage: binary [--config path] <command>
+
+commands:
+  serve                          запустить HTTP-сервер (по умолчанию)
+  migrate up [--to version]      применить миграции (все или до version включительно)
+  migrate down [--to version]    откатить последнюю миграцию или все после version
+  migrate redo                   откатить и заново применить последнюю миграцию
+  migrate status                 показать применённые и ожидающие миграции
+  seed list [--tenant id]        показать применённые тестовые наборы
+  seed apply <dataset> [--tenant id] [--users N] [--seed S]
+                                 добавить тестовый набор: demo или load-test
+  seed remove <dataset> [--tenant id]
+                                 удалить пользователей набора вместе с их подписками
+`
+
 func main() {
+	configPath := flag.String("config", "", "path to config.yaml (default: next to the executable)")
+	flag.Usage = func() { fmt.Fprint(flag.CommandLine.Output(), usage) }
+	flag.Parse()
+
 	lg := logger.New()
 	lg.Info("starting application")
-	cfg, err := config.Load(lg)
+	cfg, err := config.Load(lg, *configPath)
 	if err != nil {
 		lg.Error("error loading config", "error", err)
-		return
+		os.Exit(1)
 	}
 	lg.Info("config loaded",
 		"db_host", cfg.Postgres.Address,
 		"db_name", cfg.Postgres.DbName,
 		"http_port", cfg.Server.Port,
 	)
-	db, err := storage.New(lg,
-		cfg.Postgres.User,
-		cfg.Postgres.Password,
-		cfg.Postgres.Address,
-		cfg.Postgres.DbName)
-	if err != nil {
-		lg.Error("error connecting to database", "error", err)
+
+	args := flag.Args()
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
 	}
-	lg.Info("database connection established")
-
-	defer func() {
-		err = db.Close()
-		if err != nil {
-			lg.Error("error closing database connection", "error", err)
-		} else {
-			lg.Info("database connection closed")
+	switch command {
+	case "serve":
+		if err = serve(lg, cfg); err != nil {
+			lg.Error("application stopped with error", "error", err)
+			os.Exit(1)
 		}
-	}()
-	err = db.Migrate(migrate.Up)
-	if err != nil {
-		lg.Error("error migrating database", "error", err)
+	case "migrate":
+		if err = migrateCommand(lg, cfg, args); err != nil {
+			lg.Error("migrate command failed", "error", err)
+			os.Exit(1)
+		}
+	case "seed":
+		if err = seedCommand(lg, cfg, args); err != nil {
+			lg.Error("seed command failed", "error", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
 	}
-	lg.Info("database migration complete")
-	srv := server.New(lg, cfg.Server.Port, db)
-	lg.Info("server initialized", "port", cfg.Server.Port)
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+// serve запускает компоненты по порядку: трассировка, база, миграции, фоновые
+// задачи, кэш, HTTP-сервер, и останавливает их в обратном порядке по SIGINT или SIGTERM.
+func serve(lg *slog.Logger, cfg *config.Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
-	wg := new(sync.WaitGroup)
-	wg.Add(2)
-
-	go func() {
-		lg.Info("server starting...")
-		err = srv.Run()
-		if err != nil {
-			lg.Error("error running server", "error", err)
-			return
+
+	// остановке сервера отводится shutdown_timeout, остальным компонентам — столько же
+	app := lifecycle.New(lg, cfg.Server.ShutdownTimeout)
+
+	var mon *metrics.Metrics
+	if cfg.Server.Metrics {
+		mon = metrics.New(lg)
+	}
+
+	var shutdownTracing func(context.Context) error
+	app.Add(lifecycle.Component{
+		Name: "tracing",
+		Start: func(ctx context.Context) error {
+			var err error
+			shutdownTracing, err = tracing.Setup(ctx, lg, cfg.Tracing)
+			return err
+		},
+		// спаны последних запросов выгружаются после остановки сервера
+		Stop: func(ctx context.Context) error { return shutdownTracing(ctx) },
+	})
+
+	var db *storage.Storage
+	app.Add(lifecycle.Component{
+		Name: "database",
+		Start: func(ctx context.Context) error {
+			var err error
+			db, err = connectStorage(ctx, lg, cfg)
+			return err
+		},
+		Stop: func(context.Context) error { return db.Close() },
+	})
+
+	if cfg.Postgres.AutoMigrate {
+		app.Add(lifecycle.Component{
+			Name:  "migrations",
+			Start: func(context.Context) error { return db.Migrate(migrate.Up) },
+		})
+	}
+
+	if interval := cfg.Postgres.HealthCheckInterval; interval > 0 {
+		watchCtx, cancelWatch := context.WithCancel(context.Background())
+		var report func(up bool)
+		if mon != nil {
+			report = mon.ObserveDBConnection
 		}
-		lg.Info("server stopped gracefully")
-		wg.Done()
-	}()
-
-	go func() {
-		<-ctx.Done()
-		lg.Info("shutdown signal received")
-		err = srv.ShutDown()
-		if err != nil {
-			lg.Error("error shutting down server", "error", err)
-		} else {
-			lg.Info("server shutdown gracefully")
+		app.Add(lifecycle.Component{
+			Name: "database-watch",
+			Start: func(context.Context) error {
+				if report != nil {
+					report(true)
+				}
+				return nil
+			},
+			Run: func() error {
+				db.Watch(watchCtx, interval, report)
+				return watchCtx.Err()
+			},
+			Stop: func(context.Context) error {
+				cancelWatch()
+				return nil
+			},
+		})
+	}
+
+	var subsCache *cache.Cache
+	if cfg.Cache.Enabled {
+		var report func(method string, hit bool)
+		if mon != nil {
+			report = mon.ObserveCache
 		}
-		wg.Done()
-	}()
-	wg.Wait()
+		app.Add(lifecycle.Component{
+			Name: "cache",
+			Start: func(ctx context.Context) error {
+				var err error
+				subsCache, err = cache.New(ctx, lg, cfg.Cache, db, report)
+				return err
+			},
+			Stop: func(context.Context) error { return subsCache.Close() },
+		})
+	}
+
+	var srv *server.Server
+	app.Add(lifecycle.Component{
+		Name: "http",
+		Start: func(context.Context) error {
+			middlewares, err := httpMiddlewares(lg, cfg, db)
+			if err != nil {
+				return err
+			}
+			if mon != nil {
+				mon.RegisterDB(db.Pool(), cfg.Postgres.DbName)
+				mon.RegisterBusiness(db)
+			}
+			var subs storage.SubscriptionStorage = db
+			var users storage.UserStorage = db
+			var members storage.MemberStorage = db
+			var admin storage.AdminStorage = db
+			if subsCache != nil {
+				subs = subsCache.InstrumentStorage(subs)
+				users = subsCache.InstrumentUsers(users)
+				members = subsCache.InstrumentMembers(members)
+				admin = subsCache.InstrumentAdmin(admin)
+			}
+			srv = server.New(lg, cfg.Server, subs, users, members, db, admin, mon, db, middlewares...)
+			lg.Info("server initialized", "port", cfg.Server.Port)
+			return nil
+		},
+		Run:  func() error { return srv.Run() },
+		Stop: func(context.Context) error { return srv.ShutDown() },
+	})
+
+	if err := app.Run(ctx); err != nil {
+		return err
+	}
 	lg.Info("server shutdown complete")
+	return nil
+}
+
+// connectStorage подключается к базе, повторяя попытки с экспоненциальной
+// паузой по postgres.retry, пока база не станет доступна.
+func connectStorage(ctx context.Context, lg *slog.Logger, cfg *config.Config) (*storage.Storage, error) {
+	retry := cfg.Postgres.Retry
+	var db *storage.Storage
+	err := lifecycle.Retry(ctx, lg.With("component", "database"),
+		retry.Attempts, retry.InitialBackoff, retry.MaxBackoff,
+		func(context.Context) error {
+			var err error
+			db, err = storage.New(lg, cfg.Postgres)
+			return err
+		})
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	return db, nil
+}
+
+func httpMiddlewares(lg *slog.Logger, cfg *config.Config, db *storage.Storage) ([]func(http.Handler) http.Handler, error) {
+	var middlewares []func(http.Handler) http.Handler
+	if cfg.Auth.Enabled {
+		authenticators := []auth.Authenticator{auth.NewAPIKey(db)}
+		if cfg.Auth.JWT.Secret != "" || cfg.Auth.JWT.JWKSFile != "" {
+			jwtAuth, err := auth.NewJWT(cfg.Auth.JWT)
+			if err != nil {
+				return nil, fmt.Errorf("init jwt authentication: %w", err)
+			}
+			authenticators = append(authenticators, jwtAuth)
+		}
+		if len(cfg.Auth.MTLS.Identities) > 0 {
+			// заголовки Authorization важнее сертификата, поэтому mTLS проверяется последним
+			certAuth, err := auth.NewClientCert(cfg.Auth.MTLS)
+			if err != nil {
+				return nil, fmt.Errorf("init client certificate authentication: %w", err)
+			}
+			authenticators = append(authenticators, certAuth)
+		}
+		middlewares = append(middlewares, auth.Middleware(lg, cfg.Auth.AdminScope, authenticators...))
+	}
+	return append(middlewares, tenant.Middleware(lg, cfg.Tenancy)), nil
 }
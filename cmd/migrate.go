@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/config"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	migrate "github.com/rubenv/sql-migrate"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// migrateCommand выполняет "migrate up|down|redo|status" без запуска сервера.
+func migrateCommand(lg *slog.Logger, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate: subcommand is required (up, down, redo, status)")
+	}
+	sub := args[0]
+
+	fs := flag.NewFlagSet("migrate "+sub, flag.ContinueOnError)
+	to := fs.String("to", "", "target migration version, e.g. v05 or v05-api-keys.sql")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("migrate %s: unexpected arguments %v", sub, fs.Args())
+	}
+	if *to != "" && sub != "up" && sub != "down" {
+		return fmt.Errorf("migrate %s: --to is only supported by up and down", sub)
+	}
+
+	db, closeDB, err := openStorage(lg, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	switch sub {
+	case "up":
+		n, err := db.MigrateTo(migrate.Up, *to)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s)\n", n)
+	case "down":
+		n, err := db.MigrateTo(migrate.Down, *to)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("rolled back %d migration(s)\n", n)
+	case "redo":
+		id, err := db.RedoMigration()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("redone %s\n", id)
+	case "status":
+		status, err := db.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		return printMigrationStatus(status)
+	default:
+		return fmt.Errorf("migrate: unknown subcommand %q", sub)
+	}
+	return nil
+}
+
+func printMigrationStatus(status []storage.MigrationStatus) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tAPPLIED AT")
+	for _, m := range status {
+		applied := "pending"
+		if m.Applied {
+			applied = m.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", m.ID, applied)
+	}
+	return w.Flush()
+}
+
+// openStorage подключается к базе для служебных команд и возвращает функцию закрытия.
+func openStorage(lg *slog.Logger, cfg *config.Config) (*storage.Storage, func(), error) {
+	db, err := connectStorage(context.Background(), lg, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, func() {
+		if err := db.Close(); err != nil {
+			lg.Error("error closing database connection", "error", err)
+		}
+	}, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/config"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/seed"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
+	"log/slog"
+)
+
+// seedCommand выполняет "seed list|apply|remove": тестовые наборы данных
+// применяются и удаляются отдельно от миграций схемы.
+func seedCommand(lg *slog.Logger, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("seed: subcommand is required (list, apply, remove)")
+	}
+	sub, args := args[0], args[1:]
+
+	var name string
+	if sub == "apply" || sub == "remove" {
+		if len(args) == 0 || !seed.Known(args[0]) {
+			return fmt.Errorf("seed %s: dataset name is required, known: %v", sub, seed.Names)
+		}
+		name, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("seed "+sub, flag.ContinueOnError)
+	tenantID := fs.String("tenant", tenant.Default, "tenant to seed")
+	users := fs.Int("users", seed.DefaultLoadTestUsers, "number of users in the load-test dataset")
+	seedValue := fs.Uint64("seed", seed.DefaultLoadTestSeed, "random seed of the load-test dataset")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("seed %s: unexpected arguments %v", sub, fs.Args())
+	}
+	if _, ok := cfg.Tenancy.Tenants[*tenantID]; !ok {
+		return fmt.Errorf("seed %s: unknown tenant %q", sub, *tenantID)
+	}
+
+	var ds seed.Dataset
+	if sub == "apply" {
+		var err error
+		if ds, err = seed.Get(name, seed.Options{Users: *users, Seed: *seedValue}); err != nil {
+			return fmt.Errorf("seed apply: %w", err)
+		}
+	}
+
+	db, closeDB, err := openStorage(lg, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx := tenant.WithTenant(context.Background(), *tenantID, cfg.Tenancy.Tenants[*tenantID])
+
+	switch sub {
+	case "list":
+		applied, err := db.SeededDatasets(ctx)
+		if err != nil {
+			return err
+		}
+		for _, ds := range seed.Names {
+			if n, ok := applied[ds]; ok {
+				fmt.Printf("%s\tapplied (%d users)\n", ds, n)
+			} else {
+				fmt.Printf("%s\tnot applied\n", ds)
+			}
+		}
+	case "apply":
+		if err = db.ApplySeed(ctx, ds.Name, ds.Users, ds.Subscriptions); err != nil {
+			return fmt.Errorf("seed apply %s: %w", ds.Name, err)
+		}
+		fmt.Printf("applied %s: %d users, %d subscriptions\n", ds.Name, len(ds.Users), len(ds.Subscriptions))
+	case "remove":
+		n, err := db.RemoveSeed(ctx, name)
+		if err != nil {
+			return fmt.Errorf("seed remove %s: %w", name, err)
+		}
+		fmt.Printf("removed %s: %d users\n", name, n)
+	default:
+		return fmt.Errorf("seed: unknown subcommand %q", sub)
+	}
+	return nil
+}
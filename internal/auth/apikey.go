@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
+	"net/http"
+	"strings"
+)
+
+const apiKeyPrefixLen = 8
+
+type APIKeyLookup interface {
+	FindAPIKey(ctx context.Context, hash string) (*entity.APIKey, error)
+}
+
+type APIKeyAuthenticator struct {
+	keys APIKeyLookup
+}
+
+func NewAPIKey(keys APIKeyLookup) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{keys: keys}
+}
+
+// Authenticate принимает заголовок "Authorization: ApiKey <ключ>".
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "ApiKey") {
+		return Identity{}, ErrNoCredentials
+	}
+
+	key, err := a.keys.FindAPIKey(r.Context(), HashAPIKey(strings.TrimSpace(token)))
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+
+	return Identity{
+		Subject: "apikey:" + key.KeyId.String(),
+		Scopes:  key.Scopes,
+		Tenant:  key.TenantId,
+		Service: true,
+	}, nil
+}
+
+// GenerateAPIKey создаёт новый случайный ключ и возвращает его вместе
+// с коротким префиксом для отображения и хешем для хранения.
+func GenerateAPIKey() (token string, prefix string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, token[:apiKeyPrefixLen], HashAPIKey(token), nil
+}
+
+func HashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequireScope ограничивает маршрут для сервисных клиентов ключами с нужным скоупом.
+// Пользователи с JWT проходят дальше, их доступ проверяется по владельцу данных.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := FromContext(r.Context())
+			if ok && id.Service && !id.HasScope(scope) && !id.Can(PermManage) {
+				problem.Write(w, r, problem.New(http.StatusForbidden, problem.CodeInsufficientScope, "missing scope "+scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+const DefaultAdminScope = "admin"
+
+var (
+	// ErrNoCredentials означает, что в запросе нет данных для этого
+	// аутентификатора и нужно попробовать следующий.
+	ErrNoCredentials = errors.New("no credentials")
+	ErrUnauthorized  = errors.New("unauthorized")
+)
+
+type Identity struct {
+	Subject string
+	UserID  uuid.UUID
+	Scopes  []string
+	Roles   []Role
+	// Tenant — арендатор, к которому привязаны учётные данные (пусто, если не привязаны).
+	Tenant string
+	// Service — сервисный клиент с API-ключом: права определяются
+	// скоупами маршрута, а не владельцем данных.
+	Service bool
+	admin   string
+}
+
+func (i Identity) HasScope(scope string) bool {
+	return slices.Contains(i.Scopes, scope)
+}
+
+func (i Identity) IsAdmin() bool {
+	return i.HasRole(RoleAdmin) || i.HasRole(RoleOperator)
+}
+
+// AdminScope возвращает настроенный скоуп администратора.
+func (i Identity) AdminScope() string {
+	if i.admin == "" {
+		return DefaultAdminScope
+	}
+	return i.admin
+}
+
+type Authenticator interface {
+	Authenticate(r *http.Request) (Identity, error)
+}
+
+type ctxKey struct{}
+
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(ctxKey{}).(Identity)
+	return id, ok
+}
+
+// Middleware пробует аутентификаторы по очереди и кладёт найденную
+// личность в контекст запроса. Запросы без валидных учётных данных
+// получают 401.
+func Middleware(log *slog.Logger, adminScope string, authenticators ...Authenticator) func(http.Handler) http.Handler {
+	lg := log.With("module", "auth")
+	if adminScope == "" {
+		adminScope = DefaultAdminScope
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, a := range authenticators {
+				id, err := a.Authenticate(r)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					lg.Warn("authentication failed", "path", r.URL.Path, "err", err)
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", ApiKey`)
+					problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, "invalid credentials"))
+					return
+				}
+
+				id.admin = adminScope
+				// скоуп администратора равносилен роли admin
+				if id.HasScope(adminScope) && !id.HasRole(RoleAdmin) {
+					id.Roles = append(id.Roles, RoleAdmin)
+				}
+				if len(id.Roles) == 0 && !id.Service {
+					id.Roles = []Role{RoleUser}
+				}
+				next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+				return
+			}
+
+			lg.Warn("missing credentials", "path", r.URL.Path)
+			w.Header().Set("WWW-Authenticate", "Bearer, ApiKey")
+			problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, "credentials required"))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"maps"
+	"os"
+	"slices"
+)
+
+type Config struct {
+	Enabled    bool       `yaml:"enabled"`
+	AdminScope string     `yaml:"admin_scope"`
+	JWT        JWTConfig  `yaml:"jwt"`
+	MTLS       MTLSConfig `yaml:"mtls"`
+}
+
+type JWTConfig struct {
+	Secret   string `yaml:"secret"`
+	JWKSFile string `yaml:"jwks_file"`
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	var errs []error
+	if c.AdminScope == "" {
+		errs = append(errs, errors.New("admin_scope is required"))
+	}
+	if c.JWT.JWKSFile != "" {
+		if _, err := os.Stat(c.JWT.JWKSFile); err != nil {
+			errs = append(errs, fmt.Errorf("jwt.jwks_file: %w", err))
+		}
+	}
+	if c.JWT.Secret != "" && len(c.JWT.Secret) < 32 {
+		errs = append(errs, errors.New("jwt.secret must be at least 32 bytes"))
+	}
+	for _, subject := range slices.Sorted(maps.Keys(c.MTLS.Identities)) {
+		ci := c.MTLS.Identities[subject]
+		if ci.UserID != "" {
+			if _, err := uuid.Parse(ci.UserID); err != nil {
+				errs = append(errs, fmt.Errorf("mtls.identities.%s.user_id: %w", subject, err))
+			}
+		}
+		for _, role := range ci.Roles {
+			if _, ok := rolePermissions[Role(role)]; !ok {
+				errs = append(errs, fmt.Errorf("mtls.identities.%s.roles: unknown role %q", subject, role))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"net/http"
+	"strings"
+)
+
+type JWTAuthenticator struct {
+	secret []byte
+	keys   map[string]*rsa.PublicKey
+	parser *jwt.Parser
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	Scope  string   `json:"scope"`
+	Scopes []string `json:"scopes"`
+	Tenant string   `json:"tenant"`
+	Role   string   `json:"role"`
+	Roles  []string `json:"roles"`
+}
+
+func NewJWT(cfg JWTConfig) (*JWTAuthenticator, error) {
+	if cfg.Secret == "" && cfg.JWKSFile == "" {
+		return nil, errors.New("jwt: either secret or jwks_file must be configured")
+	}
+
+	a := &JWTAuthenticator{}
+	methods := make([]string, 0, 2)
+
+	if cfg.Secret != "" {
+		a.secret = []byte(cfg.Secret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: %w", err)
+		}
+		a.keys = keys
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+
+	return a, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return Identity{}, ErrNoCredentials
+	}
+
+	var c claims
+	if _, err := a.parser.ParseWithClaims(token, &c, a.key); err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	if c.Subject == "" {
+		return Identity{}, fmt.Errorf("%w: token has no subject", ErrUnauthorized)
+	}
+
+	scopes := c.Scopes
+	if c.Scope != "" {
+		scopes = append(scopes, strings.Fields(c.Scope)...)
+	}
+
+	roles := make([]Role, 0, len(c.Roles)+1)
+	for _, role := range c.Roles {
+		roles = append(roles, Role(role))
+	}
+	if c.Role != "" {
+		roles = append(roles, Role(c.Role))
+	}
+
+	// subject — идентификатор пользователя: без него выборки нечем ограничить
+	userID, err := uuid.Parse(c.Subject)
+	if err != nil || userID == uuid.Nil {
+		return Identity{}, fmt.Errorf("%w: token subject is not a user id", ErrUnauthorized)
+	}
+
+	return Identity{
+		Subject: c.Subject,
+		UserID:  userID,
+		Scopes:  scopes,
+		Roles:   roles,
+		Tenant:  c.Tenant,
+	}, nil
+}
+
+func (a *JWTAuthenticator) key(t *jwt.Token) (interface{}, error) {
+	switch t.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return a.secret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := t.Header["kid"].(string)
+		if key, ok := a.keys[kid]; ok {
+			return key, nil
+		}
+		if kid == "" && len(a.keys) == 1 {
+			for _, key := range a.keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+	}
+}
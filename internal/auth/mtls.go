@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+// CertIdentity описывает личность, которую получает клиент с сертификатом.
+type CertIdentity struct {
+	UserID  string   `yaml:"user_id"`
+	Scopes  []string `yaml:"scopes"`
+	Roles   []string `yaml:"roles"`
+	Tenant  string   `yaml:"tenant"`
+	Service bool     `yaml:"service"`
+}
+
+// MTLSConfig сопоставляет субъекты клиентских сертификатов личностям API.
+// Ключ — полный DN субъекта (например "CN=billing,O=Example") или только CN.
+type MTLSConfig struct {
+	Identities map[string]CertIdentity `yaml:"identities"`
+}
+
+type ClientCertAuthenticator struct {
+	identities map[string]Identity
+}
+
+func NewClientCert(cfg MTLSConfig) (*ClientCertAuthenticator, error) {
+	identities := make(map[string]Identity, len(cfg.Identities))
+	for subject, ci := range cfg.Identities {
+		userID := uuid.Nil
+		if ci.UserID != "" {
+			id, err := uuid.Parse(ci.UserID)
+			if err != nil {
+				return nil, fmt.Errorf("mtls: identity %q: user_id: %w", subject, err)
+			}
+			userID = id
+		}
+		roles := make([]Role, 0, len(ci.Roles))
+		for _, role := range ci.Roles {
+			roles = append(roles, Role(role))
+		}
+		identities[subject] = Identity{
+			Subject: "cert:" + subject,
+			UserID:  userID,
+			Scopes:  ci.Scopes,
+			Roles:   roles,
+			Tenant:  ci.Tenant,
+			Service: ci.Service,
+		}
+	}
+	return &ClientCertAuthenticator{identities: identities}, nil
+}
+
+// Authenticate учитывает только сертификаты, цепочку которых проверил TLS-сервер.
+// Проверенный сертификат с неизвестным субъектом отклоняется.
+func (a *ClientCertAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return Identity{}, ErrNoCredentials
+	}
+
+	subject := r.TLS.VerifiedChains[0][0].Subject
+	if id, ok := a.identities[subject.String()]; ok {
+		return id, nil
+	}
+	if id, ok := a.identities[subject.CommonName]; ok && subject.CommonName != "" {
+		return id, nil
+	}
+	return Identity{}, fmt.Errorf("%w: client certificate %q is not mapped to an identity", ErrUnauthorized, subject.String())
+}
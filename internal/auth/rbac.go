@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
+	"github.com/google/uuid"
+	"net/http"
+	"slices"
+)
+
+type Role string
+
+const (
+	RoleUser    Role = "user"
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+	// RoleOperator — оператор платформы: права admin в любом арендаторе.
+	RoleOperator Role = "operator"
+)
+
+type Permission string
+
+const (
+	// PermReadAny — чтение данных любого пользователя арендатора.
+	PermReadAny Permission = "read:any"
+	// PermWriteAny — создание и изменение данных любого пользователя.
+	PermWriteAny Permission = "write:any"
+	// PermDeleteAny — удаление данных любого пользователя.
+	PermDeleteAny Permission = "delete:any"
+	// PermManage — управление пользователями, ключами и административные эндпоинты.
+	PermManage Permission = "manage"
+	// PermAnyTenant — выбор арендатора заголовком для учётных данных без арендатора.
+	PermAnyTenant Permission = "tenant:any"
+)
+
+// Обычный пользователь не имеет глобальных прав и работает только со своими данными.
+var rolePermissions = map[Role][]Permission{
+	RoleUser:     {},
+	RoleSupport:  {PermReadAny},
+	RoleAdmin:    {PermReadAny, PermWriteAny, PermDeleteAny, PermManage},
+	RoleOperator: {PermReadAny, PermWriteAny, PermDeleteAny, PermManage, PermAnyTenant},
+}
+
+type Access int
+
+const (
+	AccessRead Access = iota
+	AccessWrite
+	AccessDelete
+)
+
+var anyPermission = map[Access]Permission{
+	AccessRead:   PermReadAny,
+	AccessWrite:  PermWriteAny,
+	AccessDelete: PermDeleteAny,
+}
+
+func (i Identity) HasRole(role Role) bool {
+	return slices.Contains(i.Roles, role)
+}
+
+func (i Identity) Can(p Permission) bool {
+	for _, role := range i.Roles {
+		if slices.Contains(rolePermissions[role], p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed сообщает, может ли субъект выполнить действие над данными пользователя owner.
+// Владелец работает со своими данными, сервисные клиенты ограничены скоупами маршрута.
+func (i Identity) Allowed(owner uuid.UUID, access Access) bool {
+	if i.Service {
+		return true
+	}
+	return i.Can(anyPermission[access]) || (i.UserID != uuid.Nil && i.UserID == owner)
+}
+
+// Require пропускает запрос, только если у субъекта есть разрешение p.
+func Require(p Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := FromContext(r.Context())
+			if ok && !id.Can(p) {
+				problem.Write(w, r, problem.Forbidden())
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
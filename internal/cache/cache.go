@@ -0,0 +1,400 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
+	"github.com/google/uuid"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// Backend хранит байтовые значения с ограниченным временем жизни.
+type Backend interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Close() error
+}
+
+// Source — откуда кэш узнаёт владельца и участников подписки, чтобы
+// сбросить кэш всех пользователей, чьи расчёты меняет запись.
+type Source interface {
+	ReadSubs(ctx context.Context, subsID uuid.UUID) (*entity.Subscription, error)
+	ListMembers(ctx context.Context, subsID uuid.UUID) ([]entity.Member, error)
+	ListUserSubs(ctx context.Context, userID uuid.UUID) ([]entity.Subscription, error)
+	MemberSubs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// Cache кэширует ReadSubs и TotalCost. Вместо удаления ключей каждая запись
+// хранит поколение: подписки для ReadSubs и пользователя для TotalCost.
+// Запись в хранилище меняет поколения, и старые значения перестают
+// совпадать, сколько бы их ни было. Поколение читается до запроса к базе,
+// поэтому результат, прочитанный до конкурентной записи, не переживёт её.
+type Cache struct {
+	lg      *slog.Logger
+	backend Backend
+	source  Source
+	cfg     Config
+	// report (если задан) получает каждое обращение к кэшу: метод и попадание.
+	report func(method string, hit bool)
+}
+
+func New(ctx context.Context, lg *slog.Logger, cfg Config, source Source, report func(method string, hit bool)) (*Cache, error) {
+	lg = lg.With("module", "cache")
+
+	var backend Backend
+	switch cfg.Backend {
+	case BackendRedis:
+		r, err := newRedis(ctx, cfg.Redis)
+		if err != nil {
+			return nil, err
+		}
+		backend = r
+	default:
+		backend = newMemory(cfg.MaxEntries)
+	}
+	lg.Info("cache initialized", "backend", cfg.Backend, "ttl", cfg.TTL)
+
+	return &Cache{lg: lg, backend: backend, source: source, cfg: cfg, report: report}, nil
+}
+
+func (c *Cache) Close() error {
+	return c.backend.Close()
+}
+
+// entry — закэшированное значение вместе с поколением, при котором оно прочитано.
+type entry[T any] struct {
+	Gen   string
+	Value T
+}
+
+func subsGenKey(ctx context.Context, subsID uuid.UUID) string {
+	return "gen:subs:" + tenant.FromContext(ctx) + ":" + subsID.String()
+}
+
+func userGenKey(ctx context.Context, userID uuid.UUID) string {
+	return "gen:user:" + tenant.FromContext(ctx) + ":" + userID.String()
+}
+
+// genTTL дольше срока значений: истёкшее поколение заменяется новым,
+// и значения, прочитанные при нём, становятся промахами раньше срока.
+func (c *Cache) genTTL() time.Duration {
+	return 2 * c.cfg.TTL
+}
+
+// generation возвращает текущее поколение ключа, создавая его при отсутствии.
+func (c *Cache) generation(ctx context.Context, key string) (string, error) {
+	gen, ok, err := c.backend.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return string(gen), nil
+	}
+	return c.bump(ctx, key)
+}
+
+func (c *Cache) bump(ctx context.Context, key string) (string, error) {
+	gen := strconv.FormatUint(rand.Uint64(), 36)
+	if err := c.backend.Set(ctx, key, []byte(gen), c.genTTL()); err != nil {
+		return "", err
+	}
+	return gen, nil
+}
+
+// invalidate меняет поколения подписки subsID (если задана) и пользователей users.
+func (c *Cache) invalidate(ctx context.Context, lg *slog.Logger, subsID uuid.UUID, users ...uuid.UUID) {
+	keys := make([]string, 0, len(users)+1)
+	if subsID != uuid.Nil {
+		keys = append(keys, subsGenKey(ctx, subsID))
+	}
+	for _, userID := range users {
+		keys = append(keys, userGenKey(ctx, userID))
+	}
+	for _, key := range keys {
+		if _, err := c.bump(ctx, key); err != nil {
+			// значение под старым поколением проживёт не дольше ttl
+			lg.Error("failed to invalidate cache", "key", key, "err", err)
+		}
+	}
+}
+
+// affectedUsers возвращает владельца и участников подписки: их расчёты
+// стоимости зависят от неё. Ошибка поиска не мешает записи и только пишется в лог.
+func (c *Cache) affectedUsers(ctx context.Context, lg *slog.Logger, subsID uuid.UUID) []uuid.UUID {
+	ctx = storage.WithPrimary(ctx)
+	var users []uuid.UUID
+	if subs, err := c.source.ReadSubs(ctx, subsID); err == nil {
+		users = append(users, subs.UserId)
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		lg.Warn("failed to look up subscription owner for cache invalidation", "subscription_id", subsID, "err", err)
+	}
+	members, err := c.source.ListMembers(ctx, subsID)
+	if err != nil {
+		lg.Warn("failed to look up subscription members for cache invalidation", "subscription_id", subsID, "err", err)
+	}
+	for _, m := range members {
+		users = append(users, m.UserId)
+	}
+	return users
+}
+
+// userLinks возвращает подписки, которые оплачивает userID, и пользователей,
+// чьи расчёты зависят от них. С members в расчёт входят и подписки, где userID
+// только участвует: удаление пользователя убирает его и из них.
+func (c *Cache) userLinks(ctx context.Context, lg *slog.Logger, userID uuid.UUID, members bool) (subsIDs, users []uuid.UUID) {
+	ctx = storage.WithPrimary(ctx)
+	users = []uuid.UUID{userID}
+	owned, err := c.source.ListUserSubs(ctx, userID)
+	if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
+		lg.Warn("failed to look up user subscriptions for cache invalidation", "user_id", userID, "err", err)
+	}
+	for _, subs := range owned {
+		subsIDs = append(subsIDs, subs.SubsID)
+		users = append(users, c.affectedUsers(ctx, lg, subs.SubsID)...)
+	}
+	if !members {
+		return subsIDs, users
+	}
+
+	shared, err := c.source.MemberSubs(ctx, userID)
+	if err != nil {
+		lg.Warn("failed to look up member subscriptions for cache invalidation", "user_id", userID, "err", err)
+	}
+	for _, subsID := range shared {
+		users = append(users, c.affectedUsers(ctx, lg, subsID)...)
+	}
+	return subsIDs, users
+}
+
+// cached возвращает значение key, если оно прочитано при текущем поколении
+// genKey, иначе вызывает load и сохраняет результат. Запросы, которым нужны
+// последние записи (storage.PrimaryRequired), в кэш не смотрят, но обновляют его.
+// Сбой кэша не ломает запрос: load выполняется напрямую.
+func cached[T any](ctx context.Context, c *Cache, lg *slog.Logger, method, genKey, key string, load func() (T, error)) (T, error) {
+	gen, err := c.generation(ctx, genKey)
+	if err != nil {
+		lg.Warn("cache unavailable, reading from storage", "err", err)
+		return load()
+	}
+
+	if !storage.PrimaryRequired(ctx) {
+		var e entry[T]
+		data, ok, err := c.backend.Get(ctx, key)
+		if err != nil {
+			lg.Warn("failed to read from cache", "key", key, "err", err)
+		} else if ok {
+			if err = gob.NewDecoder(bytes.NewReader(data)).Decode(&e); err != nil {
+				lg.Warn("failed to decode cached value", "key", key, "err", err)
+			} else if e.Gen == gen {
+				c.observe(method, true)
+				return e.Value, nil
+			}
+		}
+	}
+	c.observe(method, false)
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	var buf bytes.Buffer
+	if err = gob.NewEncoder(&buf).Encode(entry[T]{Gen: gen, Value: value}); err != nil {
+		lg.Warn("failed to encode value for cache", "key", key, "err", err)
+		return value, nil
+	}
+	if c.cfg.MaxValueBytes > 0 && buf.Len() > c.cfg.MaxValueBytes {
+		return value, nil
+	}
+	if err = c.backend.Set(ctx, key, buf.Bytes(), c.cfg.TTL); err != nil {
+		lg.Warn("failed to write to cache", "key", key, "err", err)
+	}
+	return value, nil
+}
+
+func (c *Cache) observe(method string, hit bool) {
+	if c.report != nil {
+		c.report(method, hit)
+	}
+}
+
+// subscriptionStorage — декоратор storage.SubscriptionStorage, кэширующий
+// ReadSubs и TotalCost. ListSubs не кэшируется: выборка зависит от даты
+// и при каждой записи менялась бы у всего арендатора.
+type subscriptionStorage struct {
+	c    *Cache
+	next storage.SubscriptionStorage
+}
+
+func (c *Cache) InstrumentStorage(next storage.SubscriptionStorage) storage.SubscriptionStorage {
+	return &subscriptionStorage{c: c, next: next}
+}
+
+func (s *subscriptionStorage) CreateSubs(ctx context.Context, subs *entity.Subscription) (uuid.UUID, error) {
+	id, err := s.next.CreateSubs(ctx, subs)
+	if err == nil {
+		lg := logger.WithTrace(ctx, s.c.lg).With("method", "CreateSubs")
+		s.c.invalidate(ctx, lg, uuid.Nil, subs.UserId)
+	}
+	return id, err
+}
+
+func (s *subscriptionStorage) ReadSubs(ctx context.Context, subsID uuid.UUID) (*entity.Subscription, error) {
+	lg := logger.WithTrace(ctx, s.c.lg).With("method", "ReadSubs")
+	key := "subs:" + tenant.FromContext(ctx) + ":" + subsID.String()
+	return cached(ctx, s.c, lg, "ReadSubs", subsGenKey(ctx, subsID), key, func() (*entity.Subscription, error) {
+		return s.next.ReadSubs(ctx, subsID)
+	})
+}
+
+func (s *subscriptionStorage) UpdateSubs(ctx context.Context, subsID uuid.UUID, subs *entity.Subscription) error {
+	lg := logger.WithTrace(ctx, s.c.lg).With("method", "UpdateSubs")
+	// прежний владелец теряет подписку, поэтому его кэш сбрасывается тоже
+	users := s.c.affectedUsers(ctx, lg, subsID)
+	err := s.next.UpdateSubs(ctx, subsID, subs)
+	if err == nil {
+		s.c.invalidate(ctx, lg, subsID, append(users, subs.UserId)...)
+	}
+	return err
+}
+
+func (s *subscriptionStorage) DeleteSubs(ctx context.Context, subsID uuid.UUID) error {
+	lg := logger.WithTrace(ctx, s.c.lg).With("method", "DeleteSubs")
+	users := s.c.affectedUsers(ctx, lg, subsID)
+	err := s.next.DeleteSubs(ctx, subsID)
+	if err == nil {
+		s.c.invalidate(ctx, lg, subsID, users...)
+	}
+	return err
+}
+
+func (s *subscriptionStorage) ListSubs(ctx context.Context, t time.Time, userID uuid.UUID) ([]entity.Subscription, error) {
+	return s.next.ListSubs(ctx, t, userID)
+}
+
+func (s *subscriptionStorage) TotalCost(ctx context.Context, t entity.TotalCost) (entity.CostReport, error) {
+	lg := logger.WithTrace(ctx, s.c.lg).With("method", "TotalCost")
+	key := fmt.Sprintf("cost:%s:%s:%q:%s:%s:%s", tenant.FromContext(ctx), t.UserId,
+		t.ServiceName, t.Date1.Format(time.DateOnly), t.Date2.Format(time.DateOnly), t.Proration)
+	return cached(ctx, s.c, lg, "TotalCost", userGenKey(ctx, t.UserId), key, func() (entity.CostReport, error) {
+		return s.next.TotalCost(ctx, t)
+	})
+}
+
+// memberStorage — декоратор storage.MemberStorage: состав участников меняет
+// расчёт долей у владельца и всех участников подписки.
+type memberStorage struct {
+	c    *Cache
+	next storage.MemberStorage
+}
+
+func (c *Cache) InstrumentMembers(next storage.MemberStorage) storage.MemberStorage {
+	return &memberStorage{c: c, next: next}
+}
+
+func (s *memberStorage) AddMember(ctx context.Context, member *entity.Member) error {
+	err := s.next.AddMember(ctx, member)
+	if err == nil {
+		lg := logger.WithTrace(ctx, s.c.lg).With("method", "AddMember")
+		s.c.invalidate(ctx, lg, uuid.Nil, s.c.affectedUsers(ctx, lg, member.SubsID)...)
+	}
+	return err
+}
+
+func (s *memberStorage) ListMembers(ctx context.Context, subsID uuid.UUID) ([]entity.Member, error) {
+	return s.next.ListMembers(ctx, subsID)
+}
+
+func (s *memberStorage) RemoveMember(ctx context.Context, subsID uuid.UUID, userID uuid.UUID) error {
+	err := s.next.RemoveMember(ctx, subsID, userID)
+	if err == nil {
+		lg := logger.WithTrace(ctx, s.c.lg).With("method", "RemoveMember")
+		s.c.invalidate(ctx, lg, uuid.Nil, append(s.c.affectedUsers(ctx, lg, subsID), userID)...)
+	}
+	return err
+}
+
+// adminStorage — декоратор storage.AdminStorage: удаление всех подписок
+// пользователя сбрасывает кэш этих подписок, пользователя и их участников.
+type adminStorage struct {
+	c    *Cache
+	next storage.AdminStorage
+}
+
+func (c *Cache) InstrumentAdmin(next storage.AdminStorage) storage.AdminStorage {
+	return &adminStorage{c: c, next: next}
+}
+
+func (s *adminStorage) ListAllSubs(ctx context.Context, limit int, offset int) ([]entity.Subscription, error) {
+	return s.next.ListAllSubs(ctx, limit, offset)
+}
+
+func (s *adminStorage) DeleteUserSubs(ctx context.Context, userID uuid.UUID) (int64, error) {
+	lg := logger.WithTrace(ctx, s.c.lg).With("method", "DeleteUserSubs")
+	subsIDs, users := s.c.userLinks(ctx, lg, userID, false)
+	deleted, err := s.next.DeleteUserSubs(ctx, userID)
+	if err == nil {
+		for _, subsID := range subsIDs {
+			s.c.invalidate(ctx, lg, subsID)
+		}
+		s.c.invalidate(ctx, lg, uuid.Nil, users...)
+	}
+	return deleted, err
+}
+
+func (s *adminStorage) Stats(ctx context.Context) (entity.Stats, error) {
+	return s.next.Stats(ctx)
+}
+
+// userStorage — декоратор storage.UserStorage: удаление пользователя каскадно
+// удаляет его подписки и участие в чужих, меняя расчёты их участников.
+type userStorage struct {
+	c    *Cache
+	next storage.UserStorage
+}
+
+func (c *Cache) InstrumentUsers(next storage.UserStorage) storage.UserStorage {
+	return &userStorage{c: c, next: next}
+}
+
+func (s *userStorage) CreateUser(ctx context.Context, user *entity.User) (uuid.UUID, error) {
+	return s.next.CreateUser(ctx, user)
+}
+
+func (s *userStorage) ReadUser(ctx context.Context, userID uuid.UUID) (*entity.User, error) {
+	return s.next.ReadUser(ctx, userID)
+}
+
+func (s *userStorage) UpdateUser(ctx context.Context, userID uuid.UUID, user *entity.User) error {
+	return s.next.UpdateUser(ctx, userID, user)
+}
+
+func (s *userStorage) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	lg := logger.WithTrace(ctx, s.c.lg).With("method", "DeleteUser")
+	subsIDs, users := s.c.userLinks(ctx, lg, userID, true)
+	err := s.next.DeleteUser(ctx, userID)
+	if err == nil {
+		for _, subsID := range subsIDs {
+			s.c.invalidate(ctx, lg, subsID)
+		}
+		s.c.invalidate(ctx, lg, uuid.Nil, users...)
+	}
+	return err
+}
+
+func (s *userStorage) ListUsers(ctx context.Context) ([]entity.User, error) {
+	return s.next.ListUsers(ctx)
+}
+
+func (s *userStorage) ListUserSubs(ctx context.Context, userID uuid.UUID) ([]entity.Subscription, error) {
+	return s.next.ListUserSubs(ctx, userID)
+}
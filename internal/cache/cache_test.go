@@ -0,0 +1,488 @@
+package cache
+
+import (
+	"context"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
+	"github.com/google/uuid"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore — хранилище в памяти, считающее обращения к каждому методу,
+// чтобы отличить ответ из кэша от чтения из хранилища.
+type fakeStore struct {
+	mu      sync.Mutex
+	subs    map[uuid.UUID]entity.Subscription
+	members map[uuid.UUID][]entity.Member
+	calls   map[string]int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		subs:    make(map[uuid.UUID]entity.Subscription),
+		members: make(map[uuid.UUID][]entity.Member),
+		calls:   make(map[string]int),
+	}
+}
+
+func (f *fakeStore) called(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+func (f *fakeStore) count(method string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[method]++
+}
+
+func (f *fakeStore) CreateSubs(_ context.Context, subs *entity.Subscription) (uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	subs.SubsID = uuid.New()
+	f.subs[subs.SubsID] = *subs
+	return subs.SubsID, nil
+}
+
+func (f *fakeStore) ReadSubs(_ context.Context, subsID uuid.UUID) (*entity.Subscription, error) {
+	f.count("ReadSubs")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	subs, ok := f.subs[subsID]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &subs, nil
+}
+
+func (f *fakeStore) UpdateSubs(_ context.Context, subsID uuid.UUID, subs *entity.Subscription) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subs[subsID]; !ok {
+		return storage.ErrNotFound
+	}
+	subs.SubsID = subsID
+	f.subs[subsID] = *subs
+	return nil
+}
+
+func (f *fakeStore) DeleteSubs(_ context.Context, subsID uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subs[subsID]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(f.subs, subsID)
+	delete(f.members, subsID)
+	return nil
+}
+
+func (f *fakeStore) ListSubs(context.Context, time.Time, uuid.UUID) ([]entity.Subscription, error) {
+	return nil, nil
+}
+
+// TotalCost возвращает сумму цен подписок, которые пользователь оплачивает
+// или в которых участвует.
+func (f *fakeStore) TotalCost(_ context.Context, t entity.TotalCost) (entity.CostReport, error) {
+	f.count("TotalCost")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var report entity.CostReport
+	for id, subs := range f.subs {
+		if subs.UserId == t.UserId {
+			report.PaidBy += subs.Price
+		}
+		if subs.UserId == t.UserId || slices.ContainsFunc(f.members[id], func(m entity.Member) bool { return m.UserId == t.UserId }) {
+			report.FairShare += subs.Price / (len(f.members[id]) + 1)
+		}
+	}
+	return report, nil
+}
+
+func (f *fakeStore) AddMember(_ context.Context, member *entity.Member) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.members[member.SubsID] = append(f.members[member.SubsID], *member)
+	return nil
+}
+
+func (f *fakeStore) ListMembers(_ context.Context, subsID uuid.UUID) ([]entity.Member, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.members[subsID]), nil
+}
+
+func (f *fakeStore) RemoveMember(_ context.Context, subsID uuid.UUID, userID uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.members[subsID] = slices.DeleteFunc(f.members[subsID], func(m entity.Member) bool { return m.UserId == userID })
+	return nil
+}
+
+func (f *fakeStore) CreateUser(context.Context, *entity.User) (uuid.UUID, error) {
+	return uuid.New(), nil
+}
+
+func (f *fakeStore) ReadUser(context.Context, uuid.UUID) (*entity.User, error) {
+	return &entity.User{}, nil
+}
+
+func (f *fakeStore) UpdateUser(context.Context, uuid.UUID, *entity.User) error {
+	return nil
+}
+
+// DeleteUser удаляет подписки пользователя и его участие в чужих, как каскад в базе.
+func (f *fakeStore) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	f.DeleteUserSubs(ctx, userID)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id := range f.members {
+		f.members[id] = slices.DeleteFunc(f.members[id], func(m entity.Member) bool { return m.UserId == userID })
+	}
+	return nil
+}
+
+func (f *fakeStore) ListUsers(context.Context) ([]entity.User, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) ListUserSubs(_ context.Context, userID uuid.UUID) ([]entity.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var subs []entity.Subscription
+	for _, s := range f.subs {
+		if s.UserId == userID {
+			subs = append(subs, s)
+		}
+	}
+	return subs, nil
+}
+
+func (f *fakeStore) MemberSubs(_ context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []uuid.UUID
+	for id, members := range f.members {
+		if slices.ContainsFunc(members, func(m entity.Member) bool { return m.UserId == userID }) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (f *fakeStore) ListAllSubs(context.Context, int, int) ([]entity.Subscription, error) {
+	return nil, nil
+}
+
+func (f *fakeStore) DeleteUserSubs(_ context.Context, userID uuid.UUID) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var deleted int64
+	for id, s := range f.subs {
+		if s.UserId == userID {
+			delete(f.subs, id)
+			delete(f.members, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (f *fakeStore) Stats(context.Context) (entity.Stats, error) {
+	return entity.Stats{}, nil
+}
+
+// decorated — хранилища fakeStore, обёрнутые кэшем так же, как в cmd.
+type decorated struct {
+	store   *fakeStore
+	subs    storage.SubscriptionStorage
+	members storage.MemberStorage
+	users   storage.UserStorage
+	admin   storage.AdminStorage
+}
+
+var backends = []string{BackendMemory, BackendRedis}
+
+func testConfig(t *testing.T, backend string) Config {
+	t.Helper()
+	cfg := Config{
+		Enabled:       true,
+		Backend:       backend,
+		TTL:           time.Minute,
+		MaxEntries:    100,
+		MaxValueBytes: 64 << 10,
+	}
+	if backend == BackendRedis {
+		cfg.Redis = RedisConfig{
+			Address:      startFakeRedis(t, ""),
+			KeyPrefix:    "test:",
+			Timeout:      time.Second,
+			MaxIdleConns: 2,
+		}
+	}
+	return cfg
+}
+
+func newDecorated(t *testing.T, cfg Config) *decorated {
+	t.Helper()
+	store := newFakeStore()
+	c, err := New(context.Background(), slog.New(slog.DiscardHandler), cfg, store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return &decorated{
+		store:   store,
+		subs:    c.InstrumentStorage(store),
+		members: c.InstrumentMembers(store),
+		users:   c.InstrumentUsers(store),
+		admin:   c.InstrumentAdmin(store),
+	}
+}
+
+func testContext() context.Context {
+	return tenant.WithTenant(context.Background(), "test", tenant.Settings{})
+}
+
+func (d *decorated) createSubs(t *testing.T, ctx context.Context, owner uuid.UUID, price int) uuid.UUID {
+	t.Helper()
+	id, err := d.subs.CreateSubs(ctx, &entity.Subscription{ServiceName: "music", Price: price, UserId: owner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func (d *decorated) readSubs(t *testing.T, ctx context.Context, id uuid.UUID) *entity.Subscription {
+	t.Helper()
+	subs, err := d.subs.ReadSubs(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return subs
+}
+
+func (d *decorated) totalCost(t *testing.T, ctx context.Context, userID uuid.UUID) entity.CostReport {
+	t.Helper()
+	report, err := d.subs.TotalCost(ctx, entity.TotalCost{ServiceName: "music", UserId: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+// expectLoads проверяет, что после предыдущей проверки method обращался к хранилищу want раз.
+func expectLoads(t *testing.T, store *fakeStore, method string, last *int, want int) {
+	t.Helper()
+	got := store.called(method) - *last
+	*last += got
+	if got != want {
+		t.Errorf("%s: storage loads = %d, want %d", method, got, want)
+	}
+}
+
+func TestReadSubsInvalidation(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			d := newDecorated(t, testConfig(t, backend))
+			ctx := testContext()
+			owner := uuid.New()
+			id := d.createSubs(t, ctx, owner, 300)
+			var loads int
+
+			d.readSubs(t, ctx, id)
+			d.readSubs(t, ctx, id)
+			expectLoads(t, d.store, "ReadSubs", &loads, 1)
+
+			err := d.subs.UpdateSubs(ctx, id, &entity.Subscription{ServiceName: "music", Price: 500, UserId: owner})
+			if err != nil {
+				t.Fatal(err)
+			}
+			loads = d.store.called("ReadSubs") // UpdateSubs читает владельца из хранилища
+			if got := d.readSubs(t, ctx, id); got.Price != 500 {
+				t.Errorf("price after update = %d, want 500", got.Price)
+			}
+			expectLoads(t, d.store, "ReadSubs", &loads, 1)
+
+			// другой арендатор не видит запись первого
+			other := tenant.WithTenant(context.Background(), "other", tenant.Settings{})
+			d.readSubs(t, other, id)
+			expectLoads(t, d.store, "ReadSubs", &loads, 1)
+
+			if err = d.subs.DeleteSubs(ctx, id); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = d.subs.ReadSubs(ctx, id); err != storage.ErrNotFound {
+				t.Errorf("read after delete: err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestTotalCostInvalidation(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			d := newDecorated(t, testConfig(t, backend))
+			ctx := testContext()
+			owner, member := uuid.New(), uuid.New()
+			id := d.createSubs(t, ctx, owner, 300)
+			var loads int
+
+			d.totalCost(t, ctx, owner)
+			d.totalCost(t, ctx, member)
+			d.totalCost(t, ctx, owner)
+			expectLoads(t, d.store, "TotalCost", &loads, 2)
+
+			// новый участник меняет долю владельца и свою
+			if err := d.members.AddMember(ctx, &entity.Member{SubsID: id, UserId: member}); err != nil {
+				t.Fatal(err)
+			}
+			if got := d.totalCost(t, ctx, owner); got.FairShare != 150 {
+				t.Errorf("owner fair share = %d, want 150", got.FairShare)
+			}
+			if got := d.totalCost(t, ctx, member); got.FairShare != 150 {
+				t.Errorf("member fair share = %d, want 150", got.FairShare)
+			}
+			expectLoads(t, d.store, "TotalCost", &loads, 2)
+
+			// подписка другого пользователя не трогает кэш владельца
+			d.createSubs(t, ctx, uuid.New(), 100)
+			d.totalCost(t, ctx, owner)
+			expectLoads(t, d.store, "TotalCost", &loads, 0)
+
+			if err := d.members.RemoveMember(ctx, id, member); err != nil {
+				t.Fatal(err)
+			}
+			if got := d.totalCost(t, ctx, member); got.FairShare != 0 {
+				t.Errorf("removed member fair share = %d, want 0", got.FairShare)
+			}
+			if got := d.totalCost(t, ctx, owner); got.FairShare != 300 {
+				t.Errorf("owner fair share = %d, want 300", got.FairShare)
+			}
+			expectLoads(t, d.store, "TotalCost", &loads, 2)
+		})
+	}
+}
+
+func TestAdminDeleteUserSubsInvalidation(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			d := newDecorated(t, testConfig(t, backend))
+			ctx := testContext()
+			owner, member := uuid.New(), uuid.New()
+			id := d.createSubs(t, ctx, owner, 300)
+			if err := d.members.AddMember(ctx, &entity.Member{SubsID: id, UserId: member}); err != nil {
+				t.Fatal(err)
+			}
+
+			d.readSubs(t, ctx, id)
+			d.totalCost(t, ctx, owner)
+			d.totalCost(t, ctx, member)
+
+			if _, err := d.admin.DeleteUserSubs(ctx, owner); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := d.subs.ReadSubs(ctx, id); err != storage.ErrNotFound {
+				t.Errorf("read after admin delete: err = %v, want ErrNotFound", err)
+			}
+			if got := d.totalCost(t, ctx, owner); got.PaidBy != 0 {
+				t.Errorf("owner paid after admin delete = %d, want 0", got.PaidBy)
+			}
+			if got := d.totalCost(t, ctx, member); got.FairShare != 0 {
+				t.Errorf("member fair share after admin delete = %d, want 0", got.FairShare)
+			}
+		})
+	}
+}
+
+func TestDeleteUserInvalidation(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			d := newDecorated(t, testConfig(t, backend))
+			ctx := testContext()
+			owner, member := uuid.New(), uuid.New()
+			shared := d.createSubs(t, ctx, owner, 300)
+			own := d.createSubs(t, ctx, member, 200)
+			if err := d.members.AddMember(ctx, &entity.Member{SubsID: shared, UserId: member}); err != nil {
+				t.Fatal(err)
+			}
+
+			d.readSubs(t, ctx, own)
+			if got := d.totalCost(t, ctx, owner); got.FairShare != 150 {
+				t.Fatalf("owner fair share = %d, want 150", got.FairShare)
+			}
+
+			// удаление участника возвращает владельцу всю стоимость подписки
+			if err := d.users.DeleteUser(ctx, member); err != nil {
+				t.Fatal(err)
+			}
+			if got := d.totalCost(t, ctx, owner); got.FairShare != 300 {
+				t.Errorf("owner fair share after member deletion = %d, want 300", got.FairShare)
+			}
+			if _, err := d.subs.ReadSubs(ctx, own); err != storage.ErrNotFound {
+				t.Errorf("read of deleted user's subscription: err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestPrimaryRequiredBypass(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			d := newDecorated(t, testConfig(t, backend))
+			ctx := testContext()
+			id := d.createSubs(t, ctx, uuid.New(), 300)
+			var loads int
+
+			d.readSubs(t, ctx, id)
+			d.readSubs(t, storage.WithPrimary(ctx), id)
+			d.readSubs(t, storage.WithPrimary(ctx), id)
+			expectLoads(t, d.store, "ReadSubs", &loads, 3)
+
+			// чтение в обход кэша обновляет его для остальных запросов
+			d.readSubs(t, ctx, id)
+			expectLoads(t, d.store, "ReadSubs", &loads, 0)
+		})
+	}
+}
+
+func TestTTL(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			cfg := testConfig(t, backend)
+			cfg.TTL = 50 * time.Millisecond
+			d := newDecorated(t, cfg)
+			ctx := testContext()
+			owner := uuid.New()
+			d.createSubs(t, ctx, owner, 300)
+			var loads int
+
+			d.totalCost(t, ctx, owner)
+			d.totalCost(t, ctx, owner)
+			expectLoads(t, d.store, "TotalCost", &loads, 1)
+
+			time.Sleep(2 * cfg.TTL)
+			d.totalCost(t, ctx, owner)
+			expectLoads(t, d.store, "TotalCost", &loads, 1)
+		})
+	}
+}
+
+func TestMaxValueBytes(t *testing.T) {
+	cfg := testConfig(t, BackendMemory)
+	cfg.MaxValueBytes = 1
+	d := newDecorated(t, cfg)
+	ctx := testContext()
+	id := d.createSubs(t, ctx, uuid.New(), 300)
+	var loads int
+
+	d.readSubs(t, ctx, id)
+	d.readSubs(t, ctx, id)
+	expectLoads(t, d.store, "ReadSubs", &loads, 2)
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+	// Backend — memory (LRU внутри процесса) или redis (любой сервер с протоколом Redis).
+	Backend string `yaml:"backend"`
+	// TTL — сколько хранится результат.
+	TTL time.Duration `yaml:"ttl"`
+	// MaxEntries ограничивает число записей LRU, Redis ограничивается своим maxmemory.
+	MaxEntries int `yaml:"max_entries"`
+	// MaxValueBytes — результаты больше этого размера не кэшируются.
+	MaxValueBytes int         `yaml:"max_value_bytes"`
+	Redis         RedisConfig `yaml:"redis"`
+}
+
+type RedisConfig struct {
+	Address  string `yaml:"address"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+	// KeyPrefix отделяет ключи сервиса от чужих в общей базе Redis.
+	KeyPrefix string `yaml:"key_prefix"`
+	// Timeout ограничивает подключение и каждую команду.
+	Timeout      time.Duration `yaml:"timeout"`
+	MaxIdleConns int           `yaml:"max_idle_conns"`
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	var errs []error
+	if c.TTL <= 0 {
+		errs = append(errs, errors.New("ttl must be positive"))
+	}
+	if c.MaxValueBytes < 0 {
+		errs = append(errs, errors.New("max_value_bytes must not be negative"))
+	}
+	switch c.Backend {
+	case BackendMemory:
+		if c.MaxEntries <= 0 {
+			errs = append(errs, errors.New("max_entries must be positive for the memory backend"))
+		}
+	case BackendRedis:
+		if _, _, err := net.SplitHostPort(c.Redis.Address); err != nil {
+			errs = append(errs, fmt.Errorf("redis.address: %w", err))
+		}
+		if c.Redis.DB < 0 {
+			errs = append(errs, errors.New("redis.db must not be negative"))
+		}
+		if c.Redis.Timeout <= 0 {
+			errs = append(errs, errors.New("redis.timeout must be positive"))
+		}
+		if c.Redis.MaxIdleConns < 0 {
+			errs = append(errs, errors.New("redis.max_idle_conns must not be negative"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("backend must be %s or %s", BackendMemory, BackendRedis))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// memory — LRU внутри процесса: при превышении maxEntries вытесняются
+// давно не читавшиеся записи, просроченные удаляются при чтении.
+type memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	// order — записи от недавно использованных к давно не использованным.
+	order *list.List
+}
+
+type memoryItem struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func newMemory(maxEntries int) *memory {
+	return &memory{
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element, maxEntries),
+		order:      list.New(),
+	}
+}
+
+func (m *memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	item := el.Value.(*memoryItem)
+	if time.Now().After(item.expires) {
+		m.order.Remove(el)
+		delete(m.items, key)
+		return nil, false, nil
+	}
+	m.order.MoveToFront(el)
+	return item.value, true, nil
+}
+
+func (m *memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expires := time.Now().Add(ttl)
+	if el, ok := m.items[key]; ok {
+		item := el.Value.(*memoryItem)
+		item.value, item.expires = value, expires
+		m.order.MoveToFront(el)
+		return nil
+	}
+
+	m.items[key] = m.order.PushFront(&memoryItem{key: key, value: value, expires: expires})
+	for m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryItem).key)
+	}
+	return nil
+}
+
+func (m *memory) Close() error {
+	return nil
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryEviction(t *testing.T) {
+	ctx := context.Background()
+	m := newMemory(2)
+	m.Set(ctx, "a", []byte("1"), time.Minute)
+	m.Set(ctx, "b", []byte("2"), time.Minute)
+
+	// чтение делает a недавно использованной, поэтому вытесняется b
+	if _, ok, _ := m.Get(ctx, "a"); !ok {
+		t.Fatal("a is missing before eviction")
+	}
+	m.Set(ctx, "c", []byte("3"), time.Minute)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok, _ := m.Get(ctx, key); ok != want {
+			t.Errorf("%s present = %t, want %t", key, ok, want)
+		}
+	}
+
+	// перезапись существующего ключа не вытесняет другие
+	m.Set(ctx, "c", []byte("4"), time.Minute)
+	if value, ok, _ := m.Get(ctx, "c"); !ok || string(value) != "4" {
+		t.Errorf("c = %q, %t, want \"4\", true", value, ok)
+	}
+	if _, ok, _ := m.Get(ctx, "a"); !ok {
+		t.Error("a evicted by overwrite of c")
+	}
+}
+
+func TestMemoryExpiry(t *testing.T) {
+	ctx := context.Background()
+	m := newMemory(10)
+	m.Set(ctx, "short", []byte("1"), 20*time.Millisecond)
+	m.Set(ctx, "long", []byte("2"), time.Minute)
+
+	time.Sleep(40 * time.Millisecond)
+	if _, ok, _ := m.Get(ctx, "short"); ok {
+		t.Error("expired entry is still returned")
+	}
+	if _, ok, _ := m.Get(ctx, "long"); !ok {
+		t.Error("unexpired entry is missing")
+	}
+	if m.order.Len() != 1 || len(m.items) != 1 {
+		t.Errorf("expired entry is kept: %d in order, %d in index", m.order.Len(), len(m.items))
+	}
+}
@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// redis — клиент протокола Redis (RESP2) ровно для тех команд, что нужны
+// кэшу: AUTH, SELECT, PING, GET и SET с PX. Подойдёт и Redis, и совместимый
+// сервер (KeyDB, Valkey, Dragonfly), и локальная заглушка в тестах.
+type redis struct {
+	cfg RedisConfig
+	// idle — свободные соединения; лишние после использования закрываются.
+	idle chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// redisError — ответ сервера с ошибкой; соединение после него остаётся рабочим.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// newRedis проверяет подключение сразу, чтобы ошибка в адресе или пароле
+// обнаружилась при запуске, а не на первом запросе.
+func newRedis(ctx context.Context, cfg RedisConfig) (*redis, error) {
+	r := &redis{cfg: cfg, idle: make(chan *redisConn, cfg.MaxIdleConns)}
+	if _, err := r.do(ctx, "PING"); err != nil {
+		return nil, fmt.Errorf("connect to redis %s: %w", cfg.Address, err)
+	}
+	return r, nil
+}
+
+func (r *redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", r.cfg.KeyPrefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return value, true, nil
+}
+
+func (r *redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := r.do(ctx, "SET", r.cfg.KeyPrefix+key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (r *redis) Close() error {
+	for {
+		select {
+		case conn := <-r.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do выполняет команду на свободном соединении. Соединение с ошибкой
+// ввода-вывода закрывается, чтобы не прочитать чужой ответ следующим запросом.
+func (r *redis) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(r.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err = conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reply, err := conn.command(args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.Close()
+		return nil, err
+	}
+
+	select {
+	case r.idle <- conn:
+	default:
+		conn.Close()
+	}
+	return reply, err
+}
+
+func (r *redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-r.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: r.cfg.Timeout}
+	c, err := dialer.DialContext(ctx, "tcp", r.cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: c, r: bufio.NewReader(c)}
+	if err = conn.SetDeadline(time.Now().Add(r.cfg.Timeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if r.cfg.Password != "" {
+		auth := []string{"AUTH", r.cfg.Password}
+		if r.cfg.Username != "" {
+			auth = []string{"AUTH", r.cfg.Username, r.cfg.Password}
+		}
+		if _, err = conn.command(auth...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("auth: %w", err)
+		}
+	}
+	if r.cfg.DB != 0 {
+		if _, err = conn.command("SELECT", strconv.Itoa(r.cfg.DB)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("select db: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *redisConn) command(args ...string) (any, error) {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, "\r\n"...)
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := c.Write(buf); err != nil {
+		return nil, err
+	}
+	return c.reply()
+}
+
+// reply читает один ответ: строку, ошибку, число, bulk-строку ([]byte,
+// nil для отсутствующего ключа) или массив ответов.
+func (c *redisConn) reply() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err = io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			item, err := c.reply()
+			var replyErr redisError
+			if err != nil && !errors.As(err, &replyErr) {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
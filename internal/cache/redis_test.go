@@ -0,0 +1,242 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis — сервер протокола Redis в памяти для тестов: понимает те же
+// команды, что и клиент кэша, и соблюдает срок жизни ключей из SET PX.
+type fakeRedis struct {
+	password string
+	mu       sync.Mutex
+	values   map[string]fakeValue
+}
+
+type fakeValue struct {
+	data    string
+	expires time.Time
+}
+
+// startFakeRedis запускает fakeRedis на свободном порту и возвращает его адрес.
+// Непустой password требует AUTH перед остальными командами.
+func startFakeRedis(t *testing.T, password string) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &fakeRedis{password: password, values: make(map[string]fakeValue)}
+
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		l.Close()
+		wg.Wait()
+	})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				srv.serve(conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		var reply string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			if args[len(args)-1] != f.password {
+				reply = "-WRONGPASS invalid username-password pair\r\n"
+				break
+			}
+			authed, reply = true, "+OK\r\n"
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case cmd == "PING":
+			reply = "+PONG\r\n"
+		case cmd == "SELECT":
+			reply = "+OK\r\n"
+		case cmd == "GET" && len(args) == 2:
+			reply = f.get(args[1])
+		case cmd == "SET" && len(args) == 5 && strings.ToUpper(args[3]) == "PX":
+			reply = f.set(args[1], args[2], args[4])
+		default:
+			reply = fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+		}
+		if _, err = io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) get(key string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.values[key]
+	if !ok || time.Now().After(v.expires) {
+		delete(f.values, key)
+		return "$-1\r\n"
+	}
+	return "$" + strconv.Itoa(len(v.data)) + "\r\n" + v.data + "\r\n"
+}
+
+func (f *fakeRedis) set(key, value, px string) string {
+	ms, err := strconv.ParseInt(px, 10, 64)
+	if err != nil || ms <= 0 {
+		return "-ERR invalid expire time in 'set' command\r\n"
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[key] = fakeValue{data: value, expires: time.Now().Add(time.Duration(ms) * time.Millisecond)}
+	return "+OK\r\n"
+}
+
+// readCommand читает команду клиента — массив bulk-строк.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	n, err := readHeader(r, '*')
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, errors.New("empty command")
+	}
+	args := make([]string, n)
+	for i := range args {
+		size, err := readHeader(r, '$')
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func readHeader(r *bufio.Reader, kind byte) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	if len(line) < 3 || line[0] != kind {
+		return 0, fmt.Errorf("unexpected line %q", line)
+	}
+	return strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+}
+
+func TestRedisGetSet(t *testing.T) {
+	ctx := context.Background()
+	addr := startFakeRedis(t, "")
+	r, err := newRedis(ctx, RedisConfig{Address: addr, KeyPrefix: "p:", Timeout: time.Second, MaxIdleConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if _, ok, err := r.Get(ctx, "missing"); err != nil || ok {
+		t.Fatalf("Get(missing) = %t, %v, want false, nil", ok, err)
+	}
+
+	// значение с переводами строк и нулевыми байтами передаётся как есть
+	value := []byte("line\r\nnext\x00end")
+	if err = r.Set(ctx, "key", value, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := r.Get(ctx, "key")
+	if err != nil || !ok || string(got) != string(value) {
+		t.Fatalf("Get(key) = %q, %t, %v, want %q", got, ok, err, value)
+	}
+
+	if err = r.Set(ctx, "short", value, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, ok, _ = r.Get(ctx, "short"); ok {
+		t.Error("expired key is still returned")
+	}
+}
+
+func TestRedisKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+	addr := startFakeRedis(t, "")
+	a, err := newRedis(ctx, RedisConfig{Address: addr, KeyPrefix: "a:", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newRedis(ctx, RedisConfig{Address: addr, KeyPrefix: "b:", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = a.Set(ctx, "key", []byte("1"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := b.Get(ctx, "key"); ok {
+		t.Error("key of one prefix is visible under another")
+	}
+}
+
+func TestRedisAuth(t *testing.T) {
+	ctx := context.Background()
+	addr := startFakeRedis(t, "secret")
+
+	if _, err := newRedis(ctx, RedisConfig{Address: addr, Password: "wrong", Timeout: time.Second}); err == nil {
+		t.Error("connected with a wrong password")
+	}
+	if _, err := newRedis(ctx, RedisConfig{Address: addr, Timeout: time.Second}); err == nil {
+		t.Error("connected without a password")
+	}
+	r, err := newRedis(ctx, RedisConfig{Address: addr, Username: "default", Password: "secret", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+}
+
+func TestRedisServerError(t *testing.T) {
+	ctx := context.Background()
+	addr := startFakeRedis(t, "")
+	r, err := newRedis(ctx, RedisConfig{Address: addr, Timeout: time.Second, MaxIdleConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// ошибка сервера не ломает соединение для следующих команд
+	var replyErr redisError
+	if _, err = r.do(ctx, "UNKNOWN"); !errors.As(err, &replyErr) {
+		t.Fatalf("unknown command: err = %v, want redisError", err)
+	}
+	if _, err = r.do(ctx, "PING"); err != nil {
+		t.Errorf("PING after server error: %v", err)
+	}
+}
@@ -1,49 +1,200 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/auth"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/cache"
 	"github.com/AndreySirin/-Effective-Mobile-/internal/server"
 	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tracing"
 	"gopkg.in/yaml.v3"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 type Config struct {
 	Server   server.Config  `yaml:"server"`
 	Postgres storage.Config `yaml:"postgres"`
+	Auth     auth.Config    `yaml:"auth"`
+	Tenancy  tenant.Config  `yaml:"tenancy"`
+	Tracing  tracing.Config `yaml:"tracing"`
+	Cache    cache.Config   `yaml:"cache"`
 }
 
-func Load(lg *slog.Logger) (*Config, error) {
+// Default возвращает конфигурацию со значениями по умолчанию. Файл и переменные
+// окружения переопределяют только заданные в них поля.
+func Default() Config {
+	return Config{
+		Server: server.Config{
+			Port:              ":8080",
+			ShutdownTimeout:   3 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			MaxBodyBytes:      1 << 20,
+			ReadYourWrites:    5 * time.Second,
+		},
+		Postgres: storage.Config{
+			DbName:      "postgres",
+			User:        "postgres",
+			Address:     "localhost:5432",
+			AutoMigrate: true,
+			// prefer — поведение pgx без явного sslmode
+			SSLMode:         "prefer",
+			ConnectTimeout:  5 * time.Second,
+			ApplicationName: "subscriptions",
+			Pool: storage.PoolConfig{
+				MaxConns:        20,
+				MinConns:        2,
+				ConnMaxLifetime: 30 * time.Minute,
+				ConnMaxIdleTime: 5 * time.Minute,
+			},
+			Retry: storage.RetryConfig{
+				Attempts:       10,
+				InitialBackoff: 500 * time.Millisecond,
+				MaxBackoff:     10 * time.Second,
+			},
+			HealthCheckInterval: 10 * time.Second,
+		},
+		Auth: auth.Config{
+			AdminScope: auth.DefaultAdminScope,
+		},
+		Tenancy: tenant.Config{
+			Header: tenant.DefaultHeader,
+			Tenants: map[string]tenant.Settings{
+				tenant.Default: {Name: "Default", ListLimit: tenant.DefaultListLimit},
+			},
+		},
+		Tracing: tracing.Config{
+			Exporter:    "otlp",
+			Endpoint:    "localhost:4318",
+			ServiceName: "subscriptions",
+			SampleRatio: 1,
+		},
+		Cache: cache.Config{
+			Backend:       cache.BackendMemory,
+			TTL:           30 * time.Second,
+			MaxEntries:    10000,
+			MaxValueBytes: 64 << 10,
+			Redis: cache.RedisConfig{
+				Address:      "localhost:6379",
+				KeyPrefix:    "subscriptions:",
+				Timeout:      time.Second,
+				MaxIdleConns: 10,
+			},
+		},
+	}
+}
+
+// Load собирает конфигурацию: значения по умолчанию, затем файл path
+// (без него — config.yaml рядом с исполняемым файлом, если он есть),
+// затем переменные окружения SUBS_*. Результат проверяется целиком,
+// и в ошибке перечисляются все найденные проблемы.
+func Load(lg *slog.Logger, path string) (*Config, error) {
 	lg.Info("loading config")
 
-	exePath, err := os.Executable()
-	if err != nil {
-		lg.Error("failed to get executable path", "err", err)
-		return nil, fmt.Errorf("failed to get executable path: %w", err)
+	config := Default()
+
+	explicit := path != ""
+	if !explicit {
+		exePath, err := os.Executable()
+		if err != nil {
+			lg.Error("failed to get executable path", "err", err)
+			return nil, fmt.Errorf("failed to get executable path: %w", err)
+		}
+		path = filepath.Join(filepath.Dir(exePath), "config.yaml")
 	}
-	exeDir := filepath.Dir(exePath)
 
-	configPath := filepath.Join(exeDir, "config.yaml")
-	absConfigPath, err := filepath.Abs(configPath)
+	absConfigPath, err := filepath.Abs(path)
 	if err != nil {
-		lg.Error("failed to get absolute config path", "path", configPath, "err", err)
+		lg.Error("failed to get absolute config path", "path", path, "err", err)
 		return nil, fmt.Errorf("failed to get absolute config path: %w", err)
 	}
 
 	data, err := os.ReadFile(absConfigPath)
-	if err != nil {
+	switch {
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		lg.Info("config file not found, using defaults and environment", "path", absConfigPath)
+	case err != nil:
 		lg.Error("failed to read config file", "path", absConfigPath, "err", err)
-		return nil, fmt.Errorf("failed to read config file: %v", err)
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	default:
+		if err = decodeYAML(data, &config); err != nil {
+			lg.Error("failed to parse config file", "path", absConfigPath, "err", err)
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
 	}
 
-	var config Config
-	if err = yaml.Unmarshal(data, &config); err != nil {
-		lg.Error("failed to parse config file", "path", absConfigPath, "err", err)
-		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	applied, err := applyEnv(&config, os.Environ())
+	if err != nil {
+		lg.Error("failed to apply environment overrides", "err", err)
+		return nil, fmt.Errorf("failed to apply environment overrides: %w", err)
+	}
+	if len(applied) > 0 {
+		lg.Info("environment overrides applied", "variables", applied)
+	}
+
+	if err = config.Validate(); err != nil {
+		lg.Error("invalid config", "err", err)
+		return nil, fmt.Errorf("invalid config:\n%w", err)
 	}
 
 	lg.Info("config loaded successfully", "path", absConfigPath)
 	return &config, nil
 }
+
+// decodeYAML разбирает файл конфигурации, отвергая неизвестные ключи: опечатка
+// или неверный отступ иначе молча оставили бы значение по умолчанию.
+func decodeYAML(data []byte, config *Config) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// Validate проверяет все разделы и возвращает все найденные ошибки сразу.
+func (c Config) Validate() error {
+	sections := []struct {
+		name string
+		err  error
+	}{
+		{"server", c.Server.Validate()},
+		{"postgres", c.Postgres.Validate()},
+		{"auth", c.Auth.Validate()},
+		{"tenancy", c.Tenancy.Validate()},
+		{"tracing", c.Tracing.Validate()},
+		{"cache", c.Cache.Validate()},
+	}
+
+	var errs []error
+	for _, s := range sections {
+		if s.err == nil {
+			continue
+		}
+		for _, err := range unwrapJoined(s.err) {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func unwrapJoined(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, unwrapJoined(e)...)
+		}
+		return errs
+	}
+	return []error{err}
+}
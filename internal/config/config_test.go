@@ -0,0 +1,59 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleConfig = "../../config.yaml"
+
+func TestLoadSample(t *testing.T) {
+	if _, err := Load(slog.New(slog.DiscardHandler), sampleConfig); err != nil {
+		t.Fatalf("sample config: %v", err)
+	}
+}
+
+// TestSampleMTLSIdentities раскомментирует пример личности в config.yaml
+// и проверяет, что она попадает в auth.mtls.
+func TestSampleMTLSIdentities(t *testing.T) {
+	data, err := os.ReadFile(sampleConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := strings.Replace(string(data), "identities: {}", "identities:", 1)
+	sample = strings.ReplaceAll(sample, "\n    #  ", "\n      ")
+
+	cfg := Default()
+	if err = decodeYAML([]byte(sample), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	ci, ok := cfg.Auth.MTLS.Identities["billing-service"]
+	if !ok {
+		t.Fatalf("auth.mtls.identities = %v, want billing-service", cfg.Auth.MTLS.Identities)
+	}
+	if !ci.Service || ci.Tenant != "default" || len(ci.Scopes) != 1 || ci.Scopes[0] != "subs:read" {
+		t.Errorf("billing-service = %+v", ci)
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "auth:\n  jwt:\n    mtls:\n      identities: {}\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(slog.New(slog.DiscardHandler), path)
+	if err == nil || !strings.Contains(err.Error(), "mtls") {
+		t.Errorf("misplaced key: err = %v, want error naming mtls", err)
+	}
+}
+
+func TestEmptyFile(t *testing.T) {
+	cfg := Default()
+	if err := decodeYAML(nil, &cfg); err != nil {
+		t.Errorf("empty file: %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix — префикс переменных окружения, переопределяющих конфигурацию.
+// Имя переменной складывается из yaml-ключей пути к полю: postgres.password →
+// SUBS_POSTGRES_PASSWORD, tenancy.tenants.acme.list_limit → SUBS_TENANCY_TENANTS_ACME_LIST_LIMIT.
+// Переменная с суффиксом _FILE задаёт путь к файлу со значением (Docker secrets).
+const EnvPrefix = "SUBS"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv переопределяет поля cfg значениями из окружения и возвращает
+// имена применённых переменных (без значений, чтобы не выводить секреты в лог).
+func applyEnv(cfg *Config, environ []string) ([]string, error) {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, EnvPrefix+"_") {
+			env[k] = v
+		}
+	}
+
+	var applied []string
+	err := walkEnv(reflect.ValueOf(cfg).Elem(), EnvPrefix, env, &applied)
+	return applied, err
+}
+
+func walkEnv(v reflect.Value, name string, env map[string]string, applied *[]string) error {
+	switch {
+	case v.Kind() == reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			key := envKey(v.Type().Field(i))
+			if key == "" {
+				continue
+			}
+			if err := walkEnv(v.Field(i), name+"_"+key, env, applied); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String && v.Type().Elem().Kind() == reflect.Struct:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for _, key := range mapKeys(v, name, env) {
+			item := reflect.New(v.Type().Elem()).Elem()
+			if existing := v.MapIndex(reflect.ValueOf(key)); existing.IsValid() {
+				item.Set(existing)
+			}
+			before := len(*applied)
+			if err := walkEnv(item, name+"_"+strings.ToUpper(key), env, applied); err != nil {
+				return err
+			}
+			if len(*applied) > before {
+				v.SetMapIndex(reflect.ValueOf(key), item)
+			}
+		}
+		return nil
+	}
+
+	raw, source, ok, err := lookup(name, env)
+	if err != nil || !ok {
+		return err
+	}
+	if err = setValue(v, raw); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	*applied = append(*applied, source)
+	return nil
+}
+
+// mapKeys возвращает ключи карты: уже заданные в конфигурации и новые,
+// встретившиеся в переменных окружения. Новый ключ определяется по суффиксу —
+// имени одного из полей элемента карты.
+func mapKeys(v reflect.Value, name string, env map[string]string) []string {
+	seen := make(map[string]bool)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		seen[strings.ToUpper(k.String())] = true
+		keys = append(keys, k.String())
+	}
+
+	elem := v.Type().Elem()
+	for envName := range env {
+		rest, ok := strings.CutPrefix(strings.TrimSuffix(envName, "_FILE"), name+"_")
+		if !ok {
+			continue
+		}
+		for i := 0; i < elem.NumField(); i++ {
+			field := envKey(elem.Field(i))
+			key, ok := strings.CutSuffix(rest, "_"+field)
+			if field == "" || !ok || key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			keys = append(keys, strings.ToLower(key))
+		}
+	}
+	return keys
+}
+
+func envKey(f reflect.StructField) string {
+	tag, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if tag == "-" || !f.IsExported() {
+		return ""
+	}
+	if tag == "" {
+		tag = f.Name
+	}
+	return strings.ToUpper(tag)
+}
+
+// lookup ищет значение в переменной name, а затем в файле из name_FILE.
+func lookup(name string, env map[string]string) (value, source string, ok bool, err error) {
+	if value, ok = env[name]; ok {
+		return value, name, true, nil
+	}
+	path, ok := env[name+"_FILE"]
+	if !ok {
+		return "", "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", false, fmt.Errorf("%s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), name + "_FILE", true, nil
+}
+
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		parts := strings.Split(raw, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		v.Set(reflect.ValueOf(parts))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyEnvFields(t *testing.T) {
+	cfg := Default()
+	applied, err := applyEnv(&cfg, []string{
+		"SUBS_POSTGRES_PASSWORD=s3cret=with=equals",
+		"SUBS_POSTGRES_POOL_MAX_CONNS=7",
+		"SUBS_POSTGRES_REPLICAS=postgres://a/db, postgres://b/db",
+		"SUBS_SERVER_SHUTDOWN_TIMEOUT=1m30s",
+		"SUBS_TRACING_SAMPLE_RATIO=0.25",
+		"SUBS_AUTH_ENABLED=true",
+		"SUBS_CACHE_REDIS_DB=3",
+		// без префикса и с неизвестным путём переменные не применяются
+		"POSTGRES_PASSWORD=ignored",
+		"SUBS_POSTGRES_UNKNOWN=ignored",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Postgres.Password != "s3cret=with=equals" {
+		t.Errorf("postgres.password = %q", cfg.Postgres.Password)
+	}
+	if cfg.Postgres.Pool.MaxConns != 7 {
+		t.Errorf("postgres.pool.max_conns = %d, want 7", cfg.Postgres.Pool.MaxConns)
+	}
+	if want := []string{"postgres://a/db", "postgres://b/db"}; !slices.Equal(cfg.Postgres.Replicas, want) {
+		t.Errorf("postgres.replicas = %q, want %q", cfg.Postgres.Replicas, want)
+	}
+	if cfg.Server.ShutdownTimeout != 90*time.Second {
+		t.Errorf("server.shutdown_timeout = %s, want 1m30s", cfg.Server.ShutdownTimeout)
+	}
+	if cfg.Tracing.SampleRatio != 0.25 {
+		t.Errorf("tracing.sample_ratio = %g, want 0.25", cfg.Tracing.SampleRatio)
+	}
+	if !cfg.Auth.Enabled {
+		t.Error("auth.enabled = false, want true")
+	}
+	if cfg.Cache.Redis.DB != 3 {
+		t.Errorf("cache.redis.db = %d, want 3", cfg.Cache.Redis.DB)
+	}
+
+	// соседние поля сохраняют значения по умолчанию
+	if def := Default(); cfg.Postgres.Pool.MinConns != def.Postgres.Pool.MinConns || cfg.Postgres.User != def.Postgres.User {
+		t.Errorf("untouched fields changed: min_conns = %d, user = %q", cfg.Postgres.Pool.MinConns, cfg.Postgres.User)
+	}
+
+	slices.Sort(applied)
+	want := []string{
+		"SUBS_AUTH_ENABLED",
+		"SUBS_CACHE_REDIS_DB",
+		"SUBS_POSTGRES_PASSWORD",
+		"SUBS_POSTGRES_POOL_MAX_CONNS",
+		"SUBS_POSTGRES_REPLICAS",
+		"SUBS_SERVER_SHUTDOWN_TIMEOUT",
+		"SUBS_TRACING_SAMPLE_RATIO",
+	}
+	if !slices.Equal(applied, want) {
+		t.Errorf("applied = %q, want %q", applied, want)
+	}
+}
+
+func TestApplyEnvMaps(t *testing.T) {
+	cfg := Default()
+	_, err := applyEnv(&cfg, []string{
+		"SUBS_SERVER_RATE_LIMIT_GROUPS_COST_RATE=2.5",
+		"SUBS_SERVER_RATE_LIMIT_GROUPS_COST_BURST=4",
+		"SUBS_SERVER_RATE_LIMIT_GROUPS_BULK_IMPORT_RATE=1",
+		// суффикс не совпадает ни с одним полем — группа не создаётся
+		"SUBS_SERVER_RATE_LIMIT_GROUPS_ADMIN_LIMIT=5",
+		"SUBS_TENANCY_TENANTS_DEFAULT_LIST_LIMIT=50",
+		"SUBS_TENANCY_TENANTS_ACME_NAME=Acme",
+		"SUBS_TENANCY_TENANTS_ACME_DEFAULT_CURRENCY=USD",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups := cfg.Server.RateLimit.Groups
+	if cost := groups["cost"]; cost.Rate != 2.5 || cost.Burst != 4 {
+		t.Errorf("groups.cost = %+v, want rate 2.5, burst 4", cost)
+	}
+	if bulk, ok := groups["bulk_import"]; !ok || bulk.Rate != 1 {
+		t.Errorf("groups.bulk_import = %+v, %t, want rate 1", bulk, ok)
+	}
+	if _, ok := groups["admin"]; ok {
+		t.Error("group admin created from an unknown field")
+	}
+	if len(groups) != 2 {
+		t.Errorf("groups = %v, want cost and bulk_import", groups)
+	}
+
+	// изменение одного поля существующего ключа сохраняет остальные
+	if def := cfg.Tenancy.Tenants["default"]; def.ListLimit != 50 || def.Name != "Default" {
+		t.Errorf("tenants.default = %+v, want name Default, list_limit 50", def)
+	}
+	if acme := cfg.Tenancy.Tenants["acme"]; acme.Name != "Acme" || acme.DefaultCurrency != "USD" || acme.ListLimit != 0 {
+		t.Errorf("tenants.acme = %+v", acme)
+	}
+}
+
+func TestApplyEnvInvalid(t *testing.T) {
+	tests := []struct {
+		env  string
+		want string
+	}{
+		{"SUBS_SERVER_SHUTDOWN_TIMEOUT=10", "SUBS_SERVER_SHUTDOWN_TIMEOUT"},
+		{"SUBS_POSTGRES_POOL_MAX_CONNS=many", "SUBS_POSTGRES_POOL_MAX_CONNS"},
+		{"SUBS_POSTGRES_POOL_MAX_CONNS=3000000000", "SUBS_POSTGRES_POOL_MAX_CONNS"},
+		{"SUBS_AUTH_ENABLED=maybe", "SUBS_AUTH_ENABLED"},
+		{"SUBS_SERVER_RATE_LIMIT_GROUPS_COST_RATE=fast", "SUBS_SERVER_RATE_LIMIT_GROUPS_COST_RATE"},
+	}
+	for _, tt := range tests {
+		cfg := Default()
+		_, err := applyEnv(&cfg, []string{tt.env})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want error naming %s", tt.env, err, tt.want)
+		}
+	}
+}
+
+func TestApplyEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "password")
+	if err := os.WriteFile(secret, []byte("from-file\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(dir, "name")
+	if err := os.WriteFile(name, []byte("Acme\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("file", func(t *testing.T) {
+		cfg := Default()
+		applied, err := applyEnv(&cfg, []string{
+			"SUBS_POSTGRES_PASSWORD_FILE=" + secret,
+			"SUBS_TENANCY_TENANTS_ACME_NAME_FILE=" + name,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Postgres.Password != "from-file" {
+			t.Errorf("password = %q, want trailing newline trimmed", cfg.Postgres.Password)
+		}
+		if got := cfg.Tenancy.Tenants["acme"].Name; got != "Acme" {
+			t.Errorf("tenants.acme.name = %q, want Acme", got)
+		}
+		slices.Sort(applied)
+		if want := []string{"SUBS_POSTGRES_PASSWORD_FILE", "SUBS_TENANCY_TENANTS_ACME_NAME_FILE"}; !slices.Equal(applied, want) {
+			t.Errorf("applied = %q, want %q", applied, want)
+		}
+	})
+
+	t.Run("variable wins over file", func(t *testing.T) {
+		cfg := Default()
+		applied, err := applyEnv(&cfg, []string{
+			"SUBS_POSTGRES_PASSWORD_FILE=" + secret,
+			"SUBS_POSTGRES_PASSWORD=direct",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Postgres.Password != "direct" || !slices.Equal(applied, []string{"SUBS_POSTGRES_PASSWORD"}) {
+			t.Errorf("password = %q, applied = %q, want direct from SUBS_POSTGRES_PASSWORD", cfg.Postgres.Password, applied)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		cfg := Default()
+		_, err := applyEnv(&cfg, []string{"SUBS_POSTGRES_PASSWORD_FILE=" + filepath.Join(dir, "missing")})
+		if err == nil || !strings.Contains(err.Error(), "SUBS_POSTGRES_PASSWORD_FILE") {
+			t.Errorf("err = %v, want error naming SUBS_POSTGRES_PASSWORD_FILE", err)
+		}
+	})
+
+	t.Run("invalid value in file", func(t *testing.T) {
+		timeout := filepath.Join(dir, "timeout")
+		if err := os.WriteFile(timeout, []byte("soon"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cfg := Default()
+		_, err := applyEnv(&cfg, []string{"SUBS_SERVER_SHUTDOWN_TIMEOUT_FILE=" + timeout})
+		if err == nil || !strings.Contains(err.Error(), "SUBS_SERVER_SHUTDOWN_TIMEOUT_FILE") {
+			t.Errorf("err = %v, want error naming SUBS_SERVER_SHUTDOWN_TIMEOUT_FILE", err)
+		}
+	})
+}
+
+func TestValidateJoinsSections(t *testing.T) {
+	cfg := Default()
+	cfg.Postgres.Password = ""
+	cfg.Server.TLS.ClientAuth = "sometimes"
+	cfg.Tracing.Enabled = true
+	cfg.Tracing.SampleRatio = 2
+	cfg.Cache.Enabled = true
+	cfg.Cache.TTL = 0
+	cfg.Cache.MaxEntries = 0
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want errors")
+	}
+	errs := unwrapJoined(err)
+
+	prefixes := make(map[string]int)
+	for _, e := range errs {
+		section, _, _ := strings.Cut(e.Error(), ": ")
+		prefixes[section]++
+	}
+	want := map[string]int{"postgres": 1, "server": 1, "tracing": 1, "cache": 2}
+	for section, n := range want {
+		if prefixes[section] != n {
+			t.Errorf("%s errors = %d, want %d; all: %v", section, prefixes[section], n, err)
+		}
+	}
+	if len(errs) != 5 {
+		t.Errorf("got %d errors, want 5: %v", len(errs), err)
+	}
+
+	if err = Default().Validate(); err == nil || !strings.HasPrefix(err.Error(), "postgres: ") {
+		t.Errorf("default config: err = %v, want only the missing password", err)
+	}
+}
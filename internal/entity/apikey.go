@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	ScopeSubsRead   = "subs:read"
+	ScopeSubsWrite  = "subs:write"
+	ScopeCostRead   = "cost:read"
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+)
+
+var KnownScopes = []string{ScopeSubsRead, ScopeSubsWrite, ScopeCostRead, ScopeUsersRead, ScopeUsersWrite}
+
+// APIKey — ключ сервисного клиента. Сам ключ показывается только
+// при создании и ротации, в базе хранится его SHA-256.
+type APIKey struct {
+	KeyId     uuid.UUID  `json:"keyId"`
+	TenantId  string     `json:"tenantId"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+}
+
+type APIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+func APIKeyToDataBase(lg *slog.Logger, req APIKeyRequest, adminScope string) (APIKey, error) {
+	lg = lg.With("module", "converter")
+	lg.Info("converting api key request to database model", "name", req.Name, "scopes", req.Scopes)
+
+	var v validator
+
+	name := strings.TrimSpace(req.Name)
+	v.length("name", name, 2, 50)
+
+	if len(req.Scopes) == 0 {
+		v.add("scopes", CodeRequired, "at least one scope is required")
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		if !slices.Contains(KnownScopes, scope) && scope != adminScope {
+			v.add(fmt.Sprintf("scopes[%d]", i), CodeUnknownScope, "unknown scope %q", scope)
+			continue
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if err := v.err(); err != nil {
+		lg.Error("api key request validation failed", "err", err)
+		return APIKey{}, err
+	}
+
+	return APIKey{
+		Name:   name,
+		Scopes: scopes,
+	}, nil
+}
@@ -0,0 +1,140 @@
+package entity
+
+import "time"
+
+// Proration — способ учёта неполных месяцев при расчёте стоимости.
+type Proration string
+
+const (
+	// ProrationNone — каждый месяц, с которым пересекается подписка, оплачивается целиком.
+	ProrationNone Proration = "none"
+	// ProrationDaily — месяц оплачивается пропорционально дням, когда подписка действовала.
+	ProrationDaily Proration = "daily"
+	// ProrationAnchor — подписка списывается в день месяца, в который она началась;
+	// последний неполный период оплачивается пропорционально использованным дням.
+	ProrationAnchor Proration = "anchor"
+)
+
+var KnownProrations = []Proration{ProrationNone, ProrationDaily, ProrationAnchor}
+
+// CostItem — подписка, участвующая в расчёте стоимости.
+type CostItem struct {
+	Price     int
+	StartDate time.Time
+	EndDate   *time.Time
+}
+
+func (c CostItem) activeOn(day time.Time) bool {
+	return !day.Before(c.StartDate) && (c.EndDate == nil || !day.After(*c.EndDate))
+}
+
+func (c CostItem) overlaps(from, to time.Time) bool {
+	return !c.StartDate.After(to) && (c.EndDate == nil || !c.EndDate.Before(from))
+}
+
+// Cost считает стоимость подписок одного сервиса за период [from, to] включительно.
+// В режимах none и daily пересекающиеся подписки не суммируются: за месяц (или день)
+// берётся самая дорогая. В режиме anchor у каждой подписки свой расчётный период,
+// и списания считаются по каждой отдельно.
+func Cost(items []CostItem, from, to time.Time, mode Proration) int {
+	switch mode {
+	case ProrationDaily:
+		return dailyCost(items, from, to)
+	case ProrationAnchor:
+		sum := 0
+		for _, item := range items {
+			sum += anchorCost(item, from, to)
+		}
+		return sum
+	default:
+		return monthlyCost(items, from, to)
+	}
+}
+
+func monthlyCost(items []CostItem, from, to time.Time) int {
+	sum := 0
+	for month := MonthStart(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+		start, end := maxTime(month, from), minTime(MonthEnd(month), to)
+		best := 0
+		for _, item := range items {
+			if item.overlaps(start, end) {
+				best = max(best, item.Price)
+			}
+		}
+		sum += best
+	}
+	return sum
+}
+
+func dailyCost(items []CostItem, from, to time.Time) int {
+	sum := 0
+	for month := MonthStart(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+		end := MonthEnd(month)
+		// сумма дневных цен, умноженная на число дней в месяце
+		scaled := 0
+		for day := maxTime(month, from); !day.After(minTime(end, to)); day = day.AddDate(0, 0, 1) {
+			best := 0
+			for _, item := range items {
+				if item.activeOn(day) {
+					best = max(best, item.Price)
+				}
+			}
+			scaled += best
+		}
+		sum += divRound(scaled, end.Day())
+	}
+	return sum
+}
+
+// anchorCost считает списания одной подписки, дата которых попадает в [from, to].
+// Списание происходит в день месяца, в который подписка началась (или в последний
+// день короткого месяца), и покрывает период до следующего списания.
+func anchorCost(item CostItem, from, to time.Time) int {
+	sum := 0
+	for k := 0; ; k++ {
+		billed := anchorDate(item.StartDate, k)
+		if billed.After(to) || (item.EndDate != nil && billed.After(*item.EndDate)) {
+			return sum
+		}
+		if billed.Before(from) {
+			continue
+		}
+
+		next := anchorDate(item.StartDate, k+1)
+		if item.EndDate == nil || !item.EndDate.Before(next) {
+			sum += item.Price
+			continue
+		}
+		used := daysBetween(billed, *item.EndDate) + 1
+		sum += divRound(item.Price*used, daysBetween(billed, next))
+	}
+}
+
+// anchorDate возвращает дату k-го списания подписки, начавшейся в start.
+func anchorDate(start time.Time, k int) time.Time {
+	month := MonthStart(start).AddDate(0, k, 0)
+	day := min(start.Day(), MonthEnd(month).Day())
+	return month.AddDate(0, 0, day-1)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours()/24 + 0.5)
+}
+
+func divRound(a, b int) int {
+	return (a + b/2) / b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,135 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func day(t *testing.T, value string) time.Time {
+	t.Helper()
+	d, err := time.Parse(DateLayout, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func item(t *testing.T, price int, start, end string) CostItem {
+	t.Helper()
+	c := CostItem{Price: price, StartDate: day(t, start)}
+	if end != "" {
+		e := day(t, end)
+		c.EndDate = &e
+	}
+	return c
+}
+
+func TestCost(t *testing.T) {
+	type sub struct {
+		price      int
+		start, end string
+	}
+	tests := []struct {
+		name     string
+		mode     Proration
+		subs     []sub
+		from, to string
+		want     int
+	}{
+		{"none: no subscriptions", ProrationNone, nil, "2024-01-01", "2024-12-31", 0},
+		{"none: open-ended", ProrationNone, []sub{{100, "2024-01-15", ""}}, "2024-01-01", "2024-03-31", 300},
+		{"none: partial first and last months are paid in full", ProrationNone,
+			[]sub{{100, "2024-01-15", "2024-02-10"}}, "2024-01-01", "2024-04-30", 200},
+		{"none: period starts and ends mid-month", ProrationNone,
+			[]sub{{100, "2024-01-01", ""}}, "2024-01-20", "2024-02-05", 200},
+		{"none: overlapping subscriptions take the most expensive per month", ProrationNone,
+			[]sub{{100, "2024-01-01", "2024-02-29"}, {300, "2024-02-15", ""}}, "2024-01-01", "2024-03-31", 700},
+		{"none: subscription outside period", ProrationNone,
+			[]sub{{100, "2023-01-01", "2023-12-31"}}, "2024-01-01", "2024-03-31", 0},
+		{"unknown mode counts whole months", "",
+			[]sub{{100, "2024-01-15", ""}}, "2024-01-01", "2024-02-29", 200},
+
+		{"daily: full month", ProrationDaily, []sub{{310, "2024-01-01", ""}}, "2024-01-01", "2024-01-31", 310},
+		{"daily: partial first month", ProrationDaily, []sub{{310, "2024-01-17", ""}}, "2024-01-01", "2024-01-31", 150},
+		{"daily: partial last month of leap February", ProrationDaily,
+			[]sub{{290, "2024-02-01", "2024-02-10"}}, "2024-02-01", "2024-02-29", 100},
+		{"daily: period cuts the month", ProrationDaily,
+			[]sub{{310, "2023-12-01", ""}}, "2024-01-11", "2024-01-20", 100},
+		{"daily: overlapping subscriptions take the most expensive per day", ProrationDaily,
+			[]sub{{310, "2024-01-01", "2024-01-15"}, {620, "2024-01-10", ""}}, "2024-01-01", "2024-01-31", 530},
+		{"daily: half a unit rounds up", ProrationDaily,
+			[]sub{{2, "2023-02-01", "2023-02-07"}}, "2023-02-01", "2023-02-28", 1},
+		{"daily: below half a unit rounds down", ProrationDaily,
+			[]sub{{100, "2024-01-01", "2024-01-05"}}, "2024-01-01", "2024-01-31", 16},
+		{"daily: each month is rounded separately", ProrationDaily,
+			[]sub{{2, "2023-01-31", "2023-02-14"}}, "2023-01-01", "2023-02-28", 1},
+
+		{"anchor: open-ended", ProrationAnchor, []sub{{100, "2024-01-15", ""}}, "2024-01-01", "2024-03-31", 300},
+		{"anchor: day 31 rolls to leap February", ProrationAnchor,
+			[]sub{{100, "2024-01-31", ""}}, "2024-01-01", "2024-04-30", 400},
+		{"anchor: day 31 rolls to February 28", ProrationAnchor,
+			[]sub{{100, "2023-01-31", ""}}, "2023-02-28", "2023-02-28", 100},
+		{"anchor: day 31 returns to the 31st after February", ProrationAnchor,
+			[]sub{{100, "2024-01-31", ""}}, "2024-03-01", "2024-03-30", 0},
+		{"anchor: last partial period is prorated", ProrationAnchor,
+			[]sub{{290, "2024-01-15", "2024-03-04"}}, "2024-01-01", "2024-12-31", 480},
+		{"anchor: end on the day before next charge is a full period", ProrationAnchor,
+			[]sub{{100, "2024-01-15", "2024-02-14"}}, "2024-01-01", "2024-12-31", 100},
+		{"anchor: charges before period are skipped", ProrationAnchor,
+			[]sub{{100, "2024-01-15", ""}}, "2024-02-01", "2024-02-29", 100},
+		{"anchor: no charge date inside period", ProrationAnchor,
+			[]sub{{100, "2024-01-15", ""}}, "2024-02-16", "2024-03-14", 0},
+		{"anchor: overlapping subscriptions are charged separately", ProrationAnchor,
+			[]sub{{100, "2024-01-01", ""}, {300, "2024-01-01", ""}}, "2024-01-01", "2024-01-31", 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := make([]CostItem, 0, len(tt.subs))
+			for _, s := range tt.subs {
+				items = append(items, item(t, s.price, s.start, s.end))
+			}
+			if got := Cost(items, day(t, tt.from), day(t, tt.to), tt.mode); got != tt.want {
+				t.Errorf("Cost() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnchorDate(t *testing.T) {
+	tests := []struct {
+		start string
+		k     int
+		want  string
+	}{
+		{"2024-01-15", 0, "2024-01-15"},
+		{"2024-01-15", 13, "2025-02-15"},
+		{"2024-01-31", 1, "2024-02-29"},
+		{"2023-01-31", 1, "2023-02-28"},
+		{"2024-01-31", 2, "2024-03-31"},
+		{"2024-01-31", 3, "2024-04-30"},
+		{"2024-01-30", 1, "2024-02-29"},
+	}
+	for _, tt := range tests {
+		if got := anchorDate(day(t, tt.start), tt.k); !got.Equal(day(t, tt.want)) {
+			t.Errorf("anchorDate(%s, %d) = %s, want %s", tt.start, tt.k, got.Format(DateLayout), tt.want)
+		}
+	}
+}
+
+func TestDivRound(t *testing.T) {
+	tests := []struct{ a, b, want int }{
+		{0, 5, 0},
+		{4, 10, 0},
+		{5, 10, 1},
+		{1, 2, 1},
+		{3, 2, 2},
+		{4650, 31, 150},
+		{16430, 31, 530},
+	}
+	for _, tt := range tests {
+		if got := divRound(tt.a, tt.b); got != tt.want {
+			t.Errorf("divRound(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DateLayout — формат дат в ответах API (ISO 8601, YYYY-MM-DD).
+const DateLayout = "2006-01-02"
+
+const dateFormatMessage = "must be a date in YYYY-MM-DD or YYYY-MM format or an RFC 3339 timestamp"
+
+const (
+	monthLayout       = "2006-01"
+	legacyMonthLayout = "01-2006"
+)
+
+// ParseDate разбирает дату в формате YYYY-MM-DD, YYYY-MM или устаревшем MM-YYYY.
+// Если указан только месяц, возвращается его первый день, а при endOfMonth —
+// последний, чтобы дата окончания включала весь месяц. Метка времени RFC 3339
+// переводится в календарную дату часового пояса loc.
+//
+// Даты всегда возвращаются как полночь UTC: это календарные дни, как тип DATE
+// в базе, и сравниваются между собой без учёта часовых поясов.
+func ParseDate(value string, endOfMonth bool, loc *time.Location) (time.Time, error) {
+	if d, err := time.Parse(DateLayout, value); err == nil {
+		return d, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return CivilDate(t, loc), nil
+	}
+
+	d, err := time.Parse(monthLayout, value)
+	if err != nil {
+		var legacyErr error
+		if d, legacyErr = time.Parse(legacyMonthLayout, value); legacyErr != nil {
+			return time.Time{}, err
+		}
+	}
+	if endOfMonth {
+		d = MonthEnd(d)
+	}
+	return d, nil
+}
+
+// CivilDate возвращает календарную дату момента t в часовом поясе loc
+// (UTC, если loc не задан) в виде полуночи UTC.
+func CivilDate(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Today возвращает текущую дату в часовом поясе loc.
+func Today(loc *time.Location) time.Time {
+	return CivilDate(time.Now(), loc)
+}
+
+// LoadLocation возвращает часовой пояс name или fallback, если имя пустое.
+func LoadLocation(name string, fallback *time.Location) (*time.Location, error) {
+	if name == "" {
+		if fallback == nil {
+			return time.UTC, nil
+		}
+		return fallback, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// MonthStart возвращает первый день месяца, в который попадает t.
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// MonthEnd возвращает последний день месяца, в который попадает t.
+func MonthEnd(t time.Time) time.Time {
+	return MonthStart(t).AddDate(0, 1, -1)
+}
+
+// MarshalJSON выводит даты подписки в формате DateLayout, в том же виде,
+// в котором они принимаются на вход и хранятся в базе.
+func (s Subscription) MarshalJSON() ([]byte, error) {
+	type plain Subscription
+	out := struct {
+		plain
+		StartDate string  `json:"startDate"`
+		EndDate   *string `json:"endDate"`
+	}{
+		plain:     plain(s),
+		StartDate: s.StartDate.Format(DateLayout),
+	}
+	if s.EndDate != nil {
+		end := s.EndDate.Format(DateLayout)
+		out.EndDate = &end
+	}
+	return json.Marshal(out)
+}
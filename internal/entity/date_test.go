@@ -0,0 +1,99 @@
+package entity
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // часовые пояса не зависят от системной базы
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestParseDate(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+	tokyo := mustLocation(t, "Asia/Tokyo")
+
+	tests := []struct {
+		name       string
+		value      string
+		endOfMonth bool
+		loc        *time.Location
+		want       string
+	}{
+		{"date", "2024-01-31", false, nil, "2024-01-31"},
+		{"date is not moved to month end", "2024-02-10", true, nil, "2024-02-10"},
+		{"date ignores location", "2024-01-31", false, tokyo, "2024-01-31"},
+
+		{"month start", "2024-02", false, nil, "2024-02-01"},
+		{"month end in leap year", "2024-02", true, nil, "2024-02-29"},
+		{"month end in common year", "2023-02", true, nil, "2023-02-28"},
+		{"month end of December", "2024-12", true, nil, "2024-12-31"},
+		{"legacy month start", "02-2024", false, nil, "2024-02-01"},
+		{"legacy month end", "02-2024", true, nil, "2024-02-29"},
+		// 2012-10 читается как октябрь 2012, а не как месяц 20 в формате MM-YYYY
+		{"YYYY-MM wins over legacy", "2012-10", false, nil, "2012-10-01"},
+
+		{"RFC 3339 in UTC", "2024-01-31T23:30:00-05:00", false, time.UTC, "2024-02-01"},
+		{"RFC 3339 without location is UTC", "2024-01-31T23:30:00-05:00", false, nil, "2024-02-01"},
+		{"RFC 3339 in its own zone", "2024-01-31T23:30:00-05:00", false, newYork, "2024-01-31"},
+		{"RFC 3339 ahead of UTC", "2024-01-31T16:00:00Z", false, tokyo, "2024-02-01"},
+		{"RFC 3339 is not moved to month end", "2024-02-10T12:00:00Z", true, time.UTC, "2024-02-10"},
+		{"RFC 3339 across year boundary", "2024-12-31T20:00:00-05:00", false, time.UTC, "2025-01-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDate(tt.value, tt.endOfMonth, tt.loc)
+			if err != nil {
+				t.Fatalf("ParseDate(%q) error: %v", tt.value, err)
+			}
+			if got.Location() != time.UTC || got.Hour() != 0 || got.Minute() != 0 {
+				t.Errorf("ParseDate(%q) = %s, want midnight UTC", tt.value, got)
+			}
+			if got.Format(DateLayout) != tt.want {
+				t.Errorf("ParseDate(%q) = %s, want %s", tt.value, got.Format(DateLayout), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"2024-02-30",
+		"2024-13",
+		"13-2024",
+		"31-01-2024",
+		"2024/01/31",
+		"2024-01-31T25:00:00Z",
+		"2024-01-31T10:00:00", // без часового пояса
+		" 2024-01-31",
+	} {
+		if got, err := ParseDate(value, false, time.UTC); err == nil {
+			t.Errorf("ParseDate(%q) = %s, want error", value, got.Format(DateLayout))
+		}
+	}
+}
+
+func TestCivilDate(t *testing.T) {
+	moment := time.Date(2024, time.March, 10, 6, 30, 0, 0, time.UTC)
+	tests := []struct {
+		loc  *time.Location
+		want string
+	}{
+		{nil, "2024-03-10"},
+		{mustLocation(t, "America/Los_Angeles"), "2024-03-09"},
+		{mustLocation(t, "Pacific/Kiritimati"), "2024-03-10"},
+	}
+	for _, tt := range tests {
+		if got := CivilDate(moment, tt.loc); got.Format(DateLayout) != tt.want || got.Location() != time.UTC {
+			t.Errorf("CivilDate(%s, %v) = %s, want %s UTC", moment, tt.loc, got, tt.want)
+		}
+	}
+}
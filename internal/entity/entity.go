@@ -1,9 +1,10 @@
 package entity
 
 import (
-	"fmt"
 	"github.com/google/uuid"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -22,6 +23,7 @@ type SubsRequest struct {
 	UserId      string `json:"userId"`
 	StartDate   string `json:"startDate"`
 	EndDate     string `json:"endDate"`
+	Timezone    string `json:"timezone"`
 }
 
 type TotalCost struct {
@@ -29,6 +31,7 @@ type TotalCost struct {
 	UserId      uuid.UUID `json:"userId"`
 	Date1       time.Time `json:"date_1"`
 	Date2       time.Time `json:"date_2"`
+	Proration   Proration `json:"proration"`
 }
 
 type TotalCostRequest struct {
@@ -36,36 +39,64 @@ type TotalCostRequest struct {
 	UserId      string `json:"userId"`
 	Date1       string `json:"date_1"`
 	Date2       string `json:"date_2"`
+	Proration   string `json:"proration"`
+	Timezone    string `json:"timezone"`
 }
 
-func SubsToDataBase(lg *slog.Logger, req SubsRequest) (Subscription, error) {
+// SubsToDataBase проверяет запрос и переводит его в модель подписки. Метки времени
+// в датах переводятся в часовой пояс запроса, а без него — в пояс владельца loc.
+func SubsToDataBase(lg *slog.Logger, req SubsRequest, loc *time.Location) (Subscription, error) {
 	lg = lg.With("module", "converter")
 	lg.Info("converting subscription request to database model", "user_id", req.UserId, "service_name", req.ServiceName)
 
-	startDate, err := time.Parse("01-2006", req.StartDate)
+	var v validator
+
+	loc, err := LoadLocation(req.Timezone, loc)
 	if err != nil {
-		lg.Error("failed to parse start date", "start_date", req.StartDate, "err", err)
-		return Subscription{}, fmt.Errorf("error parsing start date: %v", err)
+		v.add("timezone", CodeInvalidTimezone, "must be an IANA time zone name")
+	}
+
+	serviceName := strings.TrimSpace(req.ServiceName)
+	v.length("serviceName", serviceName, 2, 30)
+
+	if req.Price < 0 {
+		v.add("price", CodeInvalidRange, "must not be negative")
+	}
+
+	userId, err := uuid.Parse(req.UserId)
+	if req.UserId == "" {
+		v.add("userId", CodeRequired, "is required")
+	} else if err != nil || userId == uuid.Nil {
+		v.add("userId", CodeInvalidUUID, "must be a valid UUID")
+	}
+
+	startDate, err := ParseDate(req.StartDate, false, loc)
+	if req.StartDate == "" {
+		v.add("startDate", CodeRequired, "is required")
+	} else if err != nil {
+		v.add("startDate", CodeInvalidDate, dateFormatMessage)
 	}
 
 	var endDatePtr *time.Time
 	if req.EndDate != "" {
-		endDate, err := time.Parse("01-2006", req.EndDate)
-		if err != nil {
-			lg.Error("failed to parse end date", "end_date", req.EndDate, "err", err)
-			return Subscription{}, fmt.Errorf("error parsing end date: %v", err)
+		endDate, err := ParseDate(req.EndDate, true, loc)
+		switch {
+		case err != nil:
+			v.add("endDate", CodeInvalidDate, dateFormatMessage)
+		case !v.failed("startDate") && endDate.Before(startDate):
+			v.add("endDate", CodeInvalidRange, "must not be before startDate")
+		default:
+			endDatePtr = &endDate
 		}
-		endDatePtr = &endDate
 	}
 
-	userId, err := uuid.Parse(req.UserId)
-	if err != nil {
-		lg.Error("failed to parse user id", "user_id", req.UserId, "err", err)
-		return Subscription{}, fmt.Errorf("error parsing user id: %v", err)
+	if err = v.err(); err != nil {
+		lg.Error("subscription request validation failed", "err", err)
+		return Subscription{}, err
 	}
 
 	subs := Subscription{
-		ServiceName: req.ServiceName,
+		ServiceName: serviceName,
 		Price:       req.Price,
 		UserId:      userId,
 		StartDate:   startDate,
@@ -74,20 +105,22 @@ func SubsToDataBase(lg *slog.Logger, req SubsRequest) (Subscription, error) {
 
 	endDateStr := "nil"
 	if subs.EndDate != nil {
-		endDateStr = subs.EndDate.Format("2006-01")
+		endDateStr = subs.EndDate.Format(DateLayout)
 	}
 
 	lg.Info("subscription request converted successfully",
 		"user_id", subs.UserId,
 		"service_name", subs.ServiceName,
-		"start_date", subs.StartDate.Format("2006-01"),
+		"start_date", subs.StartDate.Format(DateLayout),
 		"end_date", endDateStr,
 	)
 
 	return subs, nil
 }
 
-func TotalCostToDataBase(lg *slog.Logger, req TotalCostRequest) (TotalCost, error) {
+// TotalCostToDataBase проверяет запрос расчёта стоимости. Часовой пояс выбирается
+// так же, как в SubsToDataBase.
+func TotalCostToDataBase(lg *slog.Logger, req TotalCostRequest, loc *time.Location) (TotalCost, error) {
 	lg = lg.With("module", "converter")
 	lg.Info("converting total cost request to database model",
 		"user_id", req.UserId,
@@ -96,41 +129,66 @@ func TotalCostToDataBase(lg *slog.Logger, req TotalCostRequest) (TotalCost, erro
 		"date2", req.Date2,
 	)
 
-	date1, err := time.Parse("01-2006", req.Date1)
+	var v validator
+
+	loc, err := LoadLocation(req.Timezone, loc)
 	if err != nil {
-		lg.Error("failed to parse start date", "date1", req.Date1, "err", err)
-		return TotalCost{}, fmt.Errorf("error parsing start date: %v", err)
+		v.add("timezone", CodeInvalidTimezone, "must be an IANA time zone name")
 	}
 
-	date2, err := time.Parse("01-2006", req.Date2)
-	if err != nil {
-		lg.Error("failed to parse end date", "date2", req.Date2, "err", err)
-		return TotalCost{}, fmt.Errorf("error parsing end date: %v", err)
+	serviceName := strings.TrimSpace(req.ServiceName)
+	v.length("serviceName", serviceName, 2, 30)
+
+	userId, err := uuid.Parse(req.UserId)
+	if req.UserId == "" {
+		v.add("userId", CodeRequired, "is required")
+	} else if err != nil || userId == uuid.Nil {
+		v.add("userId", CodeInvalidUUID, "must be a valid UUID")
 	}
 
-	if date2.Before(date1) {
-		lg.Error("invalid date range: end date before start date", "date1", date1, "date2", date2)
-		return TotalCost{}, fmt.Errorf("invalid date range: t2 before t1")
+	date1, err := ParseDate(req.Date1, false, loc)
+	if req.Date1 == "" {
+		v.add("date_1", CodeRequired, "is required")
+	} else if err != nil {
+		v.add("date_1", CodeInvalidDate, dateFormatMessage)
 	}
 
-	userId, err := uuid.Parse(req.UserId)
-	if err != nil {
-		lg.Error("failed to parse user id", "user_id", req.UserId, "err", err)
-		return TotalCost{}, fmt.Errorf("error parsing user id: %v", err)
+	date2, err := ParseDate(req.Date2, true, loc)
+	if req.Date2 == "" {
+		v.add("date_2", CodeRequired, "is required")
+	} else if err != nil {
+		v.add("date_2", CodeInvalidDate, dateFormatMessage)
+	} else if !v.failed("date_1") && date2.Before(date1) {
+		v.add("date_2", CodeInvalidRange, "must not be before date_1")
+	}
+
+	proration := Proration(req.Proration)
+	if proration == "" {
+		proration = ProrationNone
+	}
+	if !slices.Contains(KnownProrations, proration) {
+		v.add("proration", CodeInvalidValue, "must be one of none, daily, anchor")
+	}
+
+	if err = v.err(); err != nil {
+		lg.Error("total cost request validation failed", "err", err)
+		return TotalCost{}, err
 	}
 
 	T := TotalCost{
-		ServiceName: req.ServiceName,
+		ServiceName: serviceName,
 		UserId:      userId,
 		Date1:       date1,
 		Date2:       date2,
+		Proration:   proration,
 	}
 
 	lg.Info("total cost request converted successfully",
 		"user_id", T.UserId,
 		"service_name", T.ServiceName,
-		"date1", T.Date1.Format("2006-01"),
-		"date2", T.Date2.Format("2006-01"),
+		"date1", T.Date1.Format(DateLayout),
+		"date2", T.Date2.Format(DateLayout),
+		"proration", T.Proration,
 	)
 	return T, nil
 }
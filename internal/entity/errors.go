@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Коды ошибок полей запроса. Они попадают в ответ API и не должны меняться.
+const (
+	CodeRequired        = "required"
+	CodeUnknownField    = "unknown_field"
+	CodeInvalidType     = "invalid_type"
+	CodeInvalidDate     = "invalid_date"
+	CodeInvalidUUID     = "invalid_uuid"
+	CodeInvalidRange    = "invalid_range"
+	CodeInvalidLength   = "invalid_length"
+	CodeInvalidEmail    = "invalid_email"
+	CodeInvalidTimezone = "invalid_timezone"
+	CodeInvalidCurrency = "invalid_currency"
+	CodeInvalidValue    = "invalid_value"
+	CodeUnknownScope    = "unknown_scope"
+)
+
+// FieldError описывает ошибку в конкретном поле запроса.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationErrors — все ошибки полей, найденные при проверке запроса.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validator накапливает ошибки полей, чтобы вернуть их клиенту разом.
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) add(field, code, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// length проверяет длину строки в символах, как её считает VARCHAR в Postgres.
+func (v *validator) length(field, value string, minLen, maxLen int) {
+	switch n := len([]rune(value)); {
+	case n == 0:
+		v.add(field, CodeRequired, "is required")
+	case n < minLen || n > maxLen:
+		v.add(field, CodeInvalidLength, "must be between %d and %d characters", minLen, maxLen)
+	}
+}
+
+func (v *validator) failed(field string) bool {
+	for _, e := range v.errs {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
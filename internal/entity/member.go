@@ -0,0 +1,127 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"log/slog"
+)
+
+type SplitType string
+
+const (
+	SplitEqual      SplitType = "equal"
+	SplitPercentage SplitType = "percentage"
+	SplitFixed      SplitType = "fixed"
+)
+
+// Member — участник совместной подписки. Плательщиком считается владелец
+// подписки (Subscription.UserId), участники возмещают ему свою долю.
+type Member struct {
+	SubsID uuid.UUID `json:"subsId"`
+	UserId uuid.UUID `json:"userId"`
+	Split  SplitType `json:"split"`
+	Value  int       `json:"value"`
+}
+
+type MemberRequest struct {
+	UserId string `json:"userId"`
+	Split  string `json:"split"`
+	Value  int    `json:"value"`
+}
+
+// CostReport — сколько пользователь фактически заплатил за период
+// и какая часть этих расходов приходится на него при разделе подписок.
+type CostReport struct {
+	PaidBy    int       `json:"paidBy"`
+	FairShare int       `json:"fairShare"`
+	Proration Proration `json:"proration"`
+}
+
+func MemberToDataBase(lg *slog.Logger, subsID uuid.UUID, req MemberRequest) (Member, error) {
+	lg = lg.With("module", "converter")
+	lg.Info("converting member request to database model", "subs_id", subsID, "user_id", req.UserId)
+
+	var v validator
+
+	userId, err := uuid.Parse(req.UserId)
+	if req.UserId == "" {
+		v.add("userId", CodeRequired, "is required")
+	} else if err != nil || userId == uuid.Nil {
+		v.add("userId", CodeInvalidUUID, "must be a valid UUID")
+	}
+
+	split := SplitType(req.Split)
+	if split == "" {
+		split = SplitEqual
+	}
+
+	switch split {
+	case SplitEqual:
+	case SplitPercentage:
+		if req.Value <= 0 || req.Value > 100 {
+			v.add("value", CodeInvalidRange, "percentage must be between 1 and 100")
+		}
+	case SplitFixed:
+		if req.Value < 0 {
+			v.add("value", CodeInvalidRange, "fixed amount must not be negative")
+		}
+	default:
+		v.add("split", CodeInvalidValue, "must be one of equal, percentage, fixed")
+	}
+
+	if err = v.err(); err != nil {
+		lg.Error("member request validation failed", "err", err)
+		return Member{}, err
+	}
+
+	value := req.Value
+	if split == SplitEqual {
+		value = 0
+	}
+
+	return Member{
+		SubsID: subsID,
+		UserId: userId,
+		Split:  split,
+		Value:  value,
+	}, nil
+}
+
+// FairShares делит цену подписки между плательщиком и участниками:
+// сначала вычитаются фиксированные суммы, затем проценты от цены,
+// а остаток поровну делится между плательщиком и участниками с равной долей.
+// Неделимый остаток от равного деления достаётся плательщику.
+func FairShares(price int, payer uuid.UUID, members []Member) map[uuid.UUID]int {
+	shares := make(map[uuid.UUID]int, len(members)+1)
+	rest := price
+
+	for _, m := range members {
+		if m.Split == SplitFixed {
+			amount := min(m.Value, rest)
+			shares[m.UserId] = amount
+			rest -= amount
+		}
+	}
+
+	for _, m := range members {
+		if m.Split == SplitPercentage {
+			amount := min(price*m.Value/100, rest)
+			shares[m.UserId] = amount
+			rest -= amount
+		}
+	}
+
+	equal := make([]uuid.UUID, 0, len(members))
+	for _, m := range members {
+		if m.Split == SplitEqual {
+			equal = append(equal, m.UserId)
+		}
+	}
+
+	part := rest / (len(equal) + 1)
+	for _, id := range equal {
+		shares[id] = part
+	}
+	shares[payer] = rest - part*len(equal)
+
+	return shares
+}
@@ -0,0 +1,16 @@
+package entity
+
+type Stats struct {
+	Users               int            `json:"users"`
+	Subscriptions       int            `json:"subscriptions"`
+	ActiveSubscriptions int            `json:"activeSubscriptions"`
+	MonthlyRevenue      int            `json:"monthlyRevenue"`
+	Services            []ServiceStats `json:"services"`
+}
+
+type ServiceStats struct {
+	ServiceName         string `json:"serviceName"`
+	Subscriptions       int    `json:"subscriptions"`
+	ActiveSubscriptions int    `json:"activeSubscriptions"`
+	MonthlyRevenue      int    `json:"monthlyRevenue"`
+}
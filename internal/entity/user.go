@@ -0,0 +1,81 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"log/slog"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const DefaultCurrency = "RUB"
+
+// currencyCode — формат кода валюты ISO 4217: три латинские буквы.
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type User struct {
+	UserId    uuid.UUID `json:"userId"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Timezone  string    `json:"timezone"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type UserRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Timezone string `json:"timezone"`
+	Currency string `json:"currency"`
+}
+
+func UserToDataBase(lg *slog.Logger, req UserRequest) (User, error) {
+	lg = lg.With("module", "converter")
+	lg.Info("converting user request to database model", "email", req.Email)
+
+	var v validator
+
+	name := strings.TrimSpace(req.Name)
+	v.length("name", name, 2, 50)
+
+	var email string
+	if req.Email == "" {
+		v.add("email", CodeRequired, "is required")
+	} else if addr, err := mail.ParseAddress(req.Email); err != nil || len(addr.Address) > 254 {
+		v.add("email", CodeInvalidEmail, "must be a valid email address")
+	} else {
+		email = addr.Address
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil || len(timezone) > 64 {
+		v.add("timezone", CodeInvalidTimezone, "must be an IANA time zone name")
+	}
+
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if !currencyCode.MatchString(currency) {
+		v.add("currency", CodeInvalidCurrency, "must be a 3-letter ISO 4217 code")
+	}
+
+	if err := v.err(); err != nil {
+		lg.Error("user request validation failed", "err", err)
+		return User{}, err
+	}
+
+	user := User{
+		Name:     name,
+		Email:    email,
+		Timezone: timezone,
+		Currency: currency,
+	}
+
+	lg.Info("user request converted successfully", "email", user.Email, "timezone", user.Timezone)
+	return user, nil
+}
@@ -0,0 +1,149 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Component — часть приложения с управляемым жизненным циклом. Любое из
+// полей-функций может быть nil.
+type Component struct {
+	Name string
+	// Start подготавливает компонент. Ошибка прерывает запуск, уже
+	// запущенные компоненты останавливаются.
+	Start func(ctx context.Context) error
+	// Run работает до остановки компонента. Возврат до сигнала остановки
+	// считается сбоем и завершает приложение.
+	Run func() error
+	// Stop останавливает компонент; вызывается в порядке, обратном запуску.
+	Stop func(ctx context.Context) error
+}
+
+// Manager запускает компоненты по порядку и останавливает их в обратном.
+type Manager struct {
+	lg          *slog.Logger
+	stopTimeout time.Duration
+	components  []Component
+}
+
+func New(lg *slog.Logger, stopTimeout time.Duration) *Manager {
+	return &Manager{
+		lg:          lg.With("module", "lifecycle"),
+		stopTimeout: stopTimeout,
+	}
+}
+
+func (m *Manager) Add(c Component) {
+	m.components = append(m.components, c)
+}
+
+type result struct {
+	name string
+	err  error
+}
+
+// Run запускает компоненты и ждёт отмены ctx (сигнала) или завершения любого
+// Run. Затем останавливает запущенные компоненты и возвращает все ошибки:
+// ошибку запуска или сбоя и ошибки остановки.
+func (m *Manager) Run(ctx context.Context) error {
+	var errs []error
+	started := 0
+	for _, c := range m.components {
+		if c.Start != nil {
+			m.lg.Info("starting component", "component", c.Name)
+			if err := c.Start(ctx); err != nil {
+				m.lg.Error("failed to start component", "component", c.Name, "err", err)
+				errs = append(errs, fmt.Errorf("start %s: %w", c.Name, err))
+				break
+			}
+		}
+		started++
+	}
+
+	if len(errs) == 0 {
+		errs = append(errs, m.wait(ctx))
+	}
+	errs = append(errs, m.stop(started))
+	return errors.Join(errs...)
+}
+
+func (m *Manager) wait(ctx context.Context) error {
+	done := make(chan result, len(m.components))
+	running := 0
+	for _, c := range m.components {
+		if c.Run == nil {
+			continue
+		}
+		running++
+		go func() {
+			done <- result{name: c.Name, err: c.Run()}
+		}()
+	}
+	m.lg.Info("application started", "components", len(m.components))
+
+	if running == 0 {
+		<-ctx.Done()
+		m.lg.Info("shutdown signal received")
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		m.lg.Info("shutdown signal received")
+		return nil
+	case res := <-done:
+		if res.err == nil {
+			res.err = errors.New("stopped unexpectedly")
+		}
+		m.lg.Error("component failed", "component", res.name, "err", res.err)
+		return fmt.Errorf("run %s: %w", res.name, res.err)
+	}
+}
+
+// stop останавливает первые n компонентов в обратном порядке. Каждому
+// отводится stopTimeout, ошибка одного не мешает остановке остальных.
+func (m *Manager) stop(n int) error {
+	var errs []error
+	for i := n - 1; i >= 0; i-- {
+		c := m.components[i]
+		if c.Stop == nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), m.stopTimeout)
+		err := c.Stop(ctx)
+		cancel()
+		if err != nil {
+			m.lg.Error("failed to stop component", "component", c.Name, "err", err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.Name, err))
+			continue
+		}
+		m.lg.Info("component stopped", "component", c.Name)
+	}
+	return errors.Join(errs...)
+}
+
+// Retry вызывает fn, пока она не вернёт nil, с экспоненциальной паузой между
+// попытками, начиная с backoff и не больше maxBackoff. Отмена ctx прерывает
+// ожидание.
+func Retry(ctx context.Context, lg *slog.Logger, attempts int, backoff, maxBackoff time.Duration, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if attempt >= attempts {
+			return fmt.Errorf("after %d attempts: %w", attempt, err)
+		}
+
+		lg.Warn("attempt failed, retrying", "attempt", attempt, "retry_in", backoff, "err", err)
+		select {
+		case <-ctx.Done():
+			return errors.Join(ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
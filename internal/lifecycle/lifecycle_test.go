@@ -0,0 +1,272 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var discard = slog.New(slog.DiscardHandler)
+
+// recorder запоминает порядок вызовов Start и Stop компонентов.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) add(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recorder) got() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+func (r *recorder) component(name string) Component {
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			r.add("start " + name)
+			return nil
+		},
+		Stop: func(context.Context) error {
+			r.add("stop " + name)
+			return nil
+		},
+	}
+}
+
+func TestRunStopsInReverseOrder(t *testing.T) {
+	var rec recorder
+	m := New(discard, time.Second)
+	for _, name := range []string{"a", "b", "c"} {
+		m.Add(rec.component(name))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.Run(ctx); err != nil {
+		t.Fatalf("Run() = %v, want nil after shutdown signal", err)
+	}
+	want := []string{"start a", "start b", "start c", "stop c", "stop b", "stop a"}
+	if got := rec.got(); !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestRunStartFailure(t *testing.T) {
+	var rec recorder
+	startErr := errors.New("no database")
+	m := New(discard, time.Second)
+	m.Add(rec.component("a"))
+	failing := rec.component("b")
+	failing.Start = func(context.Context) error {
+		rec.add("start b")
+		return startErr
+	}
+	m.Add(failing)
+	m.Add(rec.component("c"))
+
+	err := m.Run(context.Background())
+	if !errors.Is(err, startErr) || !strings.Contains(err.Error(), "start b") {
+		t.Errorf("Run() = %v, want start b error", err)
+	}
+	// незапустившийся компонент и следующие за ним не останавливаются
+	want := []string{"start a", "start b", "stop a"}
+	if got := rec.got(); !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestRunComponentFailure(t *testing.T) {
+	runErr := errors.New("listen failed")
+	tests := []struct {
+		name string
+		run  func() error
+		want error
+	}{
+		{"error", func() error { return runErr }, runErr},
+		{"early return", func() error { return nil }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rec recorder
+			m := New(discard, time.Second)
+			m.Add(rec.component("db"))
+			srv := rec.component("http")
+			srv.Run = tt.run
+			m.Add(srv)
+
+			// ctx не отменяется: Run завершается из-за сбоя компонента
+			err := m.Run(context.Background())
+			if err == nil || !strings.Contains(err.Error(), "run http") {
+				t.Fatalf("Run() = %v, want run http error", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Run() = %v, want wrapping %v", err, tt.want)
+			}
+			want := []string{"start db", "start http", "stop http", "stop db"}
+			if got := rec.got(); !slices.Equal(got, want) {
+				t.Errorf("calls = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestStopTimeoutPerComponent(t *testing.T) {
+	const stopTimeout = 30 * time.Millisecond
+	var rec recorder
+	m := New(discard, stopTimeout)
+
+	// fast останавливается последним и должен получить свой таймаут целиком
+	fast := rec.component("fast")
+	fast.Stop = func(ctx context.Context) error {
+		if ctx.Err() != nil {
+			return errors.New("got an expired context")
+		}
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) < stopTimeout/2 {
+			return errors.New("stop timeout is shared between components")
+		}
+		rec.add("stop fast")
+		return nil
+	}
+	m.Add(fast)
+
+	failing := rec.component("failing")
+	failing.Stop = func(context.Context) error {
+		rec.add("stop failing")
+		return errors.New("flush failed")
+	}
+	m.Add(failing)
+
+	// slow останавливается первым и расходует весь свой таймаут
+	slow := rec.component("slow")
+	slow.Stop = func(ctx context.Context) error {
+		<-ctx.Done()
+		rec.add("stop slow")
+		return ctx.Err()
+	}
+	m.Add(slow)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	err := m.Run(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Run() took %s, want about %s", elapsed, stopTimeout)
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "stop slow") {
+		t.Errorf("Run() = %v, want stop slow deadline error", err)
+	}
+	if !strings.Contains(err.Error(), "stop failing: flush failed") {
+		t.Errorf("Run() = %v, want stop failing error", err)
+	}
+	if strings.Contains(err.Error(), "stop fast") {
+		t.Errorf("Run() = %v, fast component failed", err)
+	}
+	want := []string{"start fast", "start failing", "start slow", "stop slow", "stop failing", "stop fast"}
+	if got := rec.got(); !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+// backoffLog собирает паузы retry_in из записей Retry.
+type backoffLog struct {
+	mu    sync.Mutex
+	waits []time.Duration
+}
+
+func (b *backoffLog) Enabled(context.Context, slog.Level) bool { return true }
+func (b *backoffLog) WithAttrs([]slog.Attr) slog.Handler       { return b }
+func (b *backoffLog) WithGroup(string) slog.Handler            { return b }
+
+func (b *backoffLog) Handle(_ context.Context, r slog.Record) error {
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "retry_in" {
+			b.mu.Lock()
+			b.waits = append(b.waits, a.Value.Duration())
+			b.mu.Unlock()
+		}
+		return true
+	})
+	return nil
+}
+
+func TestRetryBackoff(t *testing.T) {
+	var log backoffLog
+	fnErr := errors.New("connection refused")
+	var calls []time.Time
+
+	err := Retry(context.Background(), slog.New(&log), 5, 2*time.Millisecond, 5*time.Millisecond, func(context.Context) error {
+		calls = append(calls, time.Now())
+		return fnErr
+	})
+	if !errors.Is(err, fnErr) || !strings.Contains(err.Error(), "after 5 attempts") {
+		t.Errorf("Retry() = %v, want last error after 5 attempts", err)
+	}
+	if len(calls) != 5 {
+		t.Fatalf("fn called %d times, want 5", len(calls))
+	}
+
+	// пауза удваивается и упирается в maxBackoff; после последней попытки паузы нет
+	want := []time.Duration{2 * time.Millisecond, 4 * time.Millisecond, 5 * time.Millisecond, 5 * time.Millisecond}
+	if !slices.Equal(log.waits, want) {
+		t.Errorf("backoffs = %v, want %v", log.waits, want)
+	}
+	for i := 1; i < len(calls); i++ {
+		if gap := calls[i].Sub(calls[i-1]); gap < want[i-1] {
+			t.Errorf("attempt %d came after %s, want at least %s", i+1, gap, want[i-1])
+		}
+	}
+}
+
+func TestRetrySucceeds(t *testing.T) {
+	calls := 0
+	err := Retry(context.Background(), discard, 5, time.Millisecond, time.Millisecond, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Retry() = %v after %d calls, want nil after 3", err, calls)
+	}
+}
+
+func TestRetryCancelDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fnErr := errors.New("connection refused")
+	calls := 0
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	err := Retry(ctx, discard, 10, time.Hour, time.Hour, func(context.Context) error {
+		calls++
+		return fnErr
+	})
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Retry() returned after %s, want right after cancellation", elapsed)
+	}
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, fnErr) {
+		t.Errorf("Retry() = %v, want cancellation joined with last error", err)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1", calls)
+	}
+}
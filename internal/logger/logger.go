@@ -1,10 +1,48 @@
 package logger
 
 import (
+	"context"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
 	"log/slog"
 	"os"
 )
 
 func New() *slog.Logger {
-	return slog.New(slog.NewTextHandler(os.Stdout, nil))
+	return slog.New(&traceHandler{Handler: slog.NewTextHandler(os.Stdout, nil)})
+}
+
+// WithTrace добавляет к логгеру идентификаторы запроса, трейса и спана из ctx, если они есть.
+func WithTrace(ctx context.Context, lg *slog.Logger) *slog.Logger {
+	if id := middleware.GetReqID(ctx); id != "" {
+		lg = lg.With("request_id", id)
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return lg
+	}
+	return lg.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+}
+
+// traceHandler дописывает trace_id и span_id в записи, сделанные через *Context-методы slog.
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h *traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &traceHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *traceHandler) WithGroup(name string) slog.Handler {
+	return &traceHandler{Handler: h.Handler.WithGroup(name)}
 }
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+const scrapeTimeout = 3 * time.Second
+
+// ActiveSubsCounter отдаёт число активных подписок по арендаторам.
+type ActiveSubsCounter interface {
+	CountActiveSubs(ctx context.Context) (map[string]int, error)
+}
+
+type businessCollector struct {
+	m      *Metrics
+	src    ActiveSubsCounter
+	active *prometheus.Desc
+}
+
+// RegisterBusiness добавляет бизнес-метрики, которые считаются запросом к базе при каждом scrape.
+func (m *Metrics) RegisterBusiness(src ActiveSubsCounter) {
+	m.registry.MustRegister(&businessCollector{
+		m:   m,
+		src: src,
+		active: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "active"),
+			"Subscriptions active on the current date.",
+			[]string{"tenant"}, nil,
+		),
+	})
+}
+
+func (c *businessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.active
+}
+
+func (c *businessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	counts, err := c.src.CountActiveSubs(ctx)
+	if err != nil {
+		c.m.lg.Error("failed to count active subscriptions", "err", err)
+		ch <- prometheus.NewInvalidMetric(c.active, err)
+		return
+	}
+
+	for tenant, n := range counts {
+		ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, float64(n), tenant)
+	}
+}
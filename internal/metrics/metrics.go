@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"github.com/AndreySirin/-Effective-Mobile-/internal/httpx"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const namespace = "subscriptions"
+
+type Metrics struct {
+	lg       *slog.Logger
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	storageDuration *prometheus.HistogramVec
+	storageErrors   *prometheus.CounterVec
+
+	dbUp         prometheus.Gauge
+	dbReconnects prometheus.Counter
+	dbDown       atomic.Bool
+
+	cacheRequests *prometheus.CounterVec
+}
+
+func New(log *slog.Logger) *Metrics {
+	m := &Metrics{
+		lg:       log.With("module", "metrics"),
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by route pattern, method and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by route pattern, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_duration_seconds",
+			Help:      "Storage method latency.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "errors_total",
+			Help:      "Storage method errors, expected not-found results excluded.",
+		}, []string{"method"}),
+		dbUp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "up",
+			Help:      "Whether the last database health check succeeded.",
+		}),
+		dbReconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "reconnects_total",
+			Help:      "Database connections restored after an outage.",
+		}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "requests_total",
+			Help:      "Cache lookups by storage method and result (hit or miss).",
+		}, []string{"method", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.storageDuration,
+		m.storageErrors,
+		m.dbUp,
+		m.dbReconnects,
+		m.cacheRequests,
+	)
+	return m
+}
+
+// ObserveDBConnection учитывает результат проверки соединения с базой;
+// переход из недоступного состояния в доступное считается переподключением.
+func (m *Metrics) ObserveDBConnection(up bool) {
+	if up {
+		m.dbUp.Set(1)
+		if m.dbDown.Swap(false) {
+			m.dbReconnects.Inc()
+		}
+		return
+	}
+	m.dbUp.Set(0)
+	m.dbDown.Store(true)
+}
+
+// ObserveCache учитывает обращение к кэшу хранилища.
+func (m *Metrics) ObserveCache(method string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheRequests.WithLabelValues(method, result).Inc()
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware считает запросы по шаблону маршрута chi, а не по фактическому пути,
+// чтобы идентификаторы в URL не раздували число временных рядов.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := httpx.NewStatusRecorder(w)
+
+		next.ServeHTTP(rec, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := strconv.Itoa(rec.Status)
+
+		m.httpRequests.WithLabelValues(r.Method, route, status).Inc()
+		m.httpDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+	})
+}
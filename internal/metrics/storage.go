@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/google/uuid"
+	"time"
+)
+
+// subscriptionStorage — декоратор storage.SubscriptionStorage, измеряющий
+// длительность и ошибки каждого метода.
+type subscriptionStorage struct {
+	m    *Metrics
+	next storage.SubscriptionStorage
+}
+
+func (m *Metrics) InstrumentStorage(next storage.SubscriptionStorage) storage.SubscriptionStorage {
+	return &subscriptionStorage{m: m, next: next}
+}
+
+func (s *subscriptionStorage) observe(method string, start time.Time, err error) {
+	s.m.storageDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, storage.ErrNotFound) && !errors.Is(err, storage.ErrUserNotFound) {
+		s.m.storageErrors.WithLabelValues(method).Inc()
+	}
+}
+
+func (s *subscriptionStorage) CreateSubs(ctx context.Context, subs *entity.Subscription) (id uuid.UUID, err error) {
+	defer func(start time.Time) { s.observe("CreateSubs", start, err) }(time.Now())
+	return s.next.CreateSubs(ctx, subs)
+}
+
+func (s *subscriptionStorage) ReadSubs(ctx context.Context, subsID uuid.UUID) (subs *entity.Subscription, err error) {
+	defer func(start time.Time) { s.observe("ReadSubs", start, err) }(time.Now())
+	return s.next.ReadSubs(ctx, subsID)
+}
+
+func (s *subscriptionStorage) UpdateSubs(ctx context.Context, subsID uuid.UUID, subs *entity.Subscription) (err error) {
+	defer func(start time.Time) { s.observe("UpdateSubs", start, err) }(time.Now())
+	return s.next.UpdateSubs(ctx, subsID, subs)
+}
+
+func (s *subscriptionStorage) DeleteSubs(ctx context.Context, subsID uuid.UUID) (err error) {
+	defer func(start time.Time) { s.observe("DeleteSubs", start, err) }(time.Now())
+	return s.next.DeleteSubs(ctx, subsID)
+}
+
+func (s *subscriptionStorage) ListSubs(ctx context.Context, t time.Time, userID uuid.UUID) (subs []entity.Subscription, err error) {
+	defer func(start time.Time) { s.observe("ListSubs", start, err) }(time.Now())
+	return s.next.ListSubs(ctx, t, userID)
+}
+
+func (s *subscriptionStorage) TotalCost(ctx context.Context, t entity.TotalCost) (report entity.CostReport, err error) {
+	defer func(start time.Time) { s.observe("TotalCost", start, err) }(time.Now())
+	return s.next.TotalCost(ctx, t)
+}
@@ -0,0 +1,128 @@
+// Package problem формирует ответы об ошибках в формате RFC 7807
+// (application/problem+json) с машиночитаемыми кодами.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/go-chi/chi/v5/middleware"
+	"net/http"
+)
+
+const ContentType = "application/problem+json"
+
+// Коды ошибок уровня запроса. Коды ошибок полей определены в entity.
+const (
+	CodeInvalidJSON          = "invalid_json"
+	CodeInvalidID            = "invalid_id"
+	CodeInvalidQuery         = "invalid_query"
+	CodeValidationFailed     = "validation_failed"
+	CodeSubscriptionNotFound = "subscription_not_found"
+	CodeUserNotFound         = "user_not_found"
+	CodeMemberNotFound       = "member_not_found"
+	CodeAPIKeyNotFound       = "api_key_not_found"
+	CodeEmailTaken           = "email_taken"
+	CodeMemberIsPayer        = "member_is_payer"
+	CodeConstraintViolation  = "constraint_violation"
+	CodeConflict             = "conflict"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeInsufficientScope    = "insufficient_scope"
+	CodeUnknownTenant        = "unknown_tenant"
+	CodeTenantMismatch       = "tenant_mismatch"
+	CodeRateLimited          = "rate_limited"
+	CodeRequestTooLarge      = "request_too_large"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeInternal             = "internal_error"
+)
+
+// Problem — тело ответа об ошибке.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	Code      string              `json:"code"`
+	RequestID string              `json:"requestId,omitempty"`
+	Errors    []entity.FieldError `json:"errors,omitempty"`
+}
+
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Code + ": " + p.Detail
+	}
+	return p.Code
+}
+
+// Validation собирает ответ 400 из ошибок полей. Если ошибка одна,
+// её код становится кодом ответа, иначе используется validation_failed.
+func Validation(errs ...entity.FieldError) *Problem {
+	code := CodeValidationFailed
+	if len(errs) == 1 {
+		code = errs[0].Code
+	}
+	p := New(http.StatusBadRequest, code, "request validation failed")
+	p.Errors = errs
+	return p
+}
+
+// FromError переводит ошибку разбора запроса в ответ: ошибки полей
+// становятся ответом 400 с их списком, остальные — invalid_json.
+func FromError(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+	var ve entity.ValidationErrors
+	if errors.As(err, &ve) {
+		return Validation(ve...)
+	}
+	var fe *entity.FieldError
+	if errors.As(err, &fe) {
+		return Validation(*fe)
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return New(http.StatusRequestEntityTooLarge, CodeRequestTooLarge,
+			fmt.Sprintf("request body must not exceed %d bytes", tooLarge.Limit))
+	}
+	return New(http.StatusBadRequest, CodeInvalidJSON, "request body is not valid JSON")
+}
+
+func Internal() *Problem {
+	return New(http.StatusInternalServerError, CodeInternal, "")
+}
+
+func Forbidden() *Problem {
+	return New(http.StatusForbidden, CodeForbidden, "")
+}
+
+func InvalidID(param string) *Problem {
+	return New(http.StatusBadRequest, CodeInvalidID, param+" must be a non-nil UUID")
+}
+
+// Write отправляет p клиенту, дополняя его адресом запроса и request ID.
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	resp := *p
+	resp.Instance = r.URL.Path
+	resp.RequestID = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(resp.Status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,30 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Config struct {
+	Enabled bool             `yaml:"enabled"`
+	Groups  map[string]Limit `yaml:"groups"`
+}
+
+// Limit — параметры token bucket: Rate запросов в секунду, Burst — ёмкость корзины.
+type Limit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	for name, limit := range c.Groups {
+		if limit.Rate < 0 {
+			errs = append(errs, fmt.Errorf("groups.%s.rate must not be negative", name))
+		}
+		if limit.Burst < 0 {
+			errs = append(errs, fmt.Errorf("groups.%s.burst must not be negative", name))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"github.com/AndreySirin/-Effective-Mobile-/internal/auth"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultGroup = "default"
+	// IPGroup — группа ограничителя по IP, стоящего перед аутентификацией.
+	IPGroup = "ip"
+)
+
+// Middleware возвращает ограничитель для группы маршрутов group. Если для группы
+// нет настроек, используются настройки группы default; без них ограничения нет.
+func Middleware(log *slog.Logger, cfg Config, group string) func(http.Handler) http.Handler {
+	limit, ok := cfg.Groups[group]
+	if !ok {
+		limit, ok = cfg.Groups[DefaultGroup]
+	}
+	return middleware(log, cfg.Enabled && ok, group, limit, clientKey)
+}
+
+// IPMiddleware ограничивает запросы по IP-адресу до аутентификации, чтобы
+// отклонённые попытки входа (перебор токенов и ключей) тоже расходовали лимит.
+// Ограничитель включается только явной группой ip: запрос уже расходует лимит
+// своей группы, и с настройками default он списывался бы дважды.
+func IPMiddleware(log *slog.Logger, cfg Config) func(http.Handler) http.Handler {
+	limit, ok := cfg.Groups[IPGroup]
+	return middleware(log, cfg.Enabled && ok, IPGroup, limit, ipKey)
+}
+
+func middleware(log *slog.Logger, enabled bool, group string, limit Limit, clientKey func(r *http.Request) string) func(http.Handler) http.Handler {
+	if !enabled || limit.Rate <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	lg := log.With("module", "ratelimit", "group", group)
+	limiter := NewLimiter(limit)
+	lg.Info("rate limiting enabled", "rate", limit.Rate, "burst", limiter.limit.Burst)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := clientKey(r)
+			res := limiter.Allow(key)
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+
+			if !res.Allowed {
+				lg.Warn("rate limit exceeded", "client", key, "path", r.URL.Path)
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, seconds(res.RetryAfter))))
+				problem.Write(w, r, problem.New(http.StatusTooManyRequests, problem.CodeRateLimited, "too many requests"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey выбирает ключ клиента: API-ключ или пользователь из аутентификации,
+// иначе IP-адрес.
+func clientKey(r *http.Request) string {
+	if id, ok := auth.FromContext(r.Context()); ok && id.Subject != "" {
+		if id.Service {
+			return id.Subject
+		}
+		return "user:" + id.Subject
+	}
+	return ipKey(r)
+}
+
+func ipKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,160 @@
+package seed
+
+import (
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/google/uuid"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Demo     = "demo"
+	LoadTest = "load-test"
+
+	DefaultLoadTestUsers = 1000
+	DefaultLoadTestSeed  = 1
+)
+
+var Names = []string{Demo, LoadTest}
+
+// namespace делает ID пользователей нагрузочного набора воспроизводимыми:
+// один и тот же seed всегда даёт одних и тех же пользователей.
+var namespace = uuid.MustParse("5e6b1d1e-7f3c-4c5a-9a52-3d2f8a9c0b10")
+
+// Dataset — набор тестовых данных, который применяется и удаляется целиком,
+// отдельно от миграций схемы.
+type Dataset struct {
+	Name          string
+	Users         []entity.User
+	Subscriptions []entity.Subscription
+}
+
+type Options struct {
+	// Users — число пользователей нагрузочного набора.
+	Users int
+	// Seed — зерно генератора нагрузочного набора.
+	Seed uint64
+}
+
+func Get(name string, opts Options) (Dataset, error) {
+	switch name {
+	case Demo:
+		return demo(), nil
+	case LoadTest:
+		if opts.Users <= 0 {
+			return Dataset{}, fmt.Errorf("%s: users must be positive", name)
+		}
+		return loadTest(opts.Users, opts.Seed), nil
+	default:
+		return Dataset{}, fmt.Errorf("unknown dataset %q, known: %v", name, Names)
+	}
+}
+
+// Known сообщает, есть ли набор с таким именем.
+func Known(name string) bool {
+	return slices.Contains(Names, name)
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func until(t time.Time) *time.Time {
+	return &t
+}
+
+// demo — демонстрационные подписки, которые раньше вставляла миграция second.sql.
+func demo() Dataset {
+	ids := make([]uuid.UUID, 6)
+	users := make([]entity.User, 0, len(ids))
+	for i := range ids {
+		// 11111111-1111-1111-1111-111111111111 и т. д., как в прежней миграции
+		ids[i] = uuid.MustParse(strings.Repeat(strconv.Itoa(i+1), 32))
+		users = append(users, entity.User{
+			UserId:   ids[i],
+			Name:     "user " + ids[i].String()[:8],
+			Email:    ids[i].String() + "@example.com",
+			Timezone: "UTC",
+			Currency: entity.DefaultCurrency,
+		})
+	}
+
+	sub := func(user int, service string, price int, start time.Time, end *time.Time) entity.Subscription {
+		return entity.Subscription{ServiceName: service, Price: price, UserId: ids[user-1], StartDate: start, EndDate: end}
+	}
+
+	return Dataset{
+		Name:  Demo,
+		Users: users,
+		Subscriptions: []entity.Subscription{
+			// пользователь 1: несколько Netflix подряд (частично пересекаются) и Spotify без конца
+			sub(1, "Netflix", 1200, date(2025, 1, 1), until(date(2025, 3, 31))),
+			sub(1, "Netflix", 1500, date(2025, 2, 1), until(date(2025, 5, 31))),
+			sub(1, "Spotify", 800, date(2025, 3, 1), nil),
+			// пользователь 2: YouTube Premium месяц за месяцем
+			sub(2, "YouTube Premium", 999, date(2025, 6, 1), until(date(2025, 6, 30))),
+			sub(2, "YouTube Premium", 999, date(2025, 7, 1), until(date(2025, 7, 31))),
+			// пользователь 3: две разные подписки, пересекаются
+			sub(3, "Disney+", 1100, date(2025, 4, 1), until(date(2025, 8, 31))),
+			sub(3, "Netflix", 1200, date(2025, 6, 1), until(date(2025, 12, 31))),
+			// пользователь 4: Spotify только в августе
+			sub(4, "Spotify", 800, date(2025, 8, 1), until(date(2025, 8, 31))),
+			// пользователь 5: Apple TV+ и Coursera пересекаются в июне–июле
+			sub(5, "Apple TV+", 1000, date(2025, 3, 1), until(date(2025, 9, 30))),
+			sub(5, "Coursera Plus", 3300, date(2025, 6, 1), until(date(2025, 7, 31))),
+			// пользователь 6: три разных подписки в июне–августе
+			sub(6, "Netflix", 1200, date(2025, 8, 1), nil),
+			sub(6, "Spotify", 800, date(2025, 7, 1), until(date(2025, 7, 31))),
+			sub(6, "YouTube Premium", 999, date(2025, 6, 1), until(date(2025, 8, 31))),
+		},
+	}
+}
+
+var (
+	services  = []string{"Netflix", "Spotify", "YouTube Premium", "Disney+", "Apple TV+", "Coursera Plus", "Yandex Plus", "Kinopoisk"}
+	timezones = []string{"UTC", "Europe/Moscow", "Asia/Yekaterinburg", "Asia/Novosibirsk", "Europe/Berlin"}
+)
+
+// loadTest генерирует users пользователей с 1–5 подписками каждый. Генератор
+// детерминирован: при одинаковых users и seed набор совпадает полностью.
+func loadTest(users int, seed uint64) Dataset {
+	r := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	ds := Dataset{
+		Name:          LoadTest,
+		Users:         make([]entity.User, 0, users),
+		Subscriptions: make([]entity.Subscription, 0, users*3),
+	}
+	base := date(2024, 1, 1)
+
+	for i := range users {
+		id := uuid.NewSHA1(namespace, fmt.Appendf(nil, "%s/%d/%d", LoadTest, seed, i))
+		ds.Users = append(ds.Users, entity.User{
+			UserId:   id,
+			Name:     fmt.Sprintf("load user %d", i),
+			Email:    fmt.Sprintf("load-%d-%d@seed.example.com", seed, i),
+			Timezone: timezones[r.IntN(len(timezones))],
+			Currency: entity.DefaultCurrency,
+		})
+
+		for range 1 + r.IntN(5) {
+			start := base.AddDate(0, r.IntN(24), r.IntN(28))
+			var end *time.Time
+			// примерно треть подписок бессрочные
+			if r.IntN(3) > 0 {
+				end = until(start.AddDate(0, 1+r.IntN(12), -1))
+			}
+			ds.Subscriptions = append(ds.Subscriptions, entity.Subscription{
+				ServiceName: services[r.IntN(len(services))],
+				Price:       100 * (1 + r.IntN(40)),
+				UserId:      id,
+				StartDate:   start,
+				EndDate:     end,
+			})
+		}
+	}
+	return ds
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"errors"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/auth"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// allowed проверяет, может ли субъект запроса выполнить действие над данными пользователя owner.
+// Если аутентификация отключена, личности в контексте нет и доступ не ограничивается.
+func allowed(r *http.Request, owner uuid.UUID, access auth.Access) bool {
+	id, ok := auth.FromContext(r.Context())
+	return !ok || id.Allowed(owner, access)
+}
+
+// visibleUser возвращает пользователя, которым ограничены выборки,
+// или uuid.Nil, если субъекту доступны данные всех пользователей.
+// ok равен false, если субъект ограничен своими данными, но пользователем
+// не является (например, mTLS-личность без user_id): такой выборке отказывается.
+func visibleUser(r *http.Request) (userID uuid.UUID, ok bool) {
+	id, authenticated := auth.FromContext(r.Context())
+	if !authenticated || id.Can(auth.PermReadAny) || id.Service {
+		return uuid.Nil, true
+	}
+	return id.UserID, id.UserID != uuid.Nil
+}
+
+func adminScope(r *http.Request) string {
+	id, _ := auth.FromContext(r.Context())
+	return id.AdminScope()
+}
+
+func forbidden(w http.ResponseWriter, r *http.Request, lg *slog.Logger) {
+	lg.Warn("access denied")
+	problem.Write(w, r, problem.Forbidden())
+}
+
+// ownerLocation проверяет доступ к данным пользователя userID из тела запроса
+// и только затем читает его часовой пояс: без доступа чужая запись не читается
+// и не влияет на разбор дат. Неверный userID здесь не проверяется, его отклонит
+// проверка запроса. При отказе ответ уже записан в w.
+func (s *Server) ownerLocation(w http.ResponseWriter, r *http.Request, lg *slog.Logger, userID string, access auth.Access) (*time.Location, bool) {
+	if id, err := uuid.Parse(userID); err == nil && id != uuid.Nil && !allowed(r, id, access) {
+		forbidden(w, r, lg)
+		return nil, false
+	}
+	return s.userLocation(r.Context(), lg, userID), true
+}
+
+// authorizeSubs проверяет, что субъект запроса может выполнить действие
+// над подпиской subsID. При отказе ответ уже записан в w.
+func (s *Server) authorizeSubs(w http.ResponseWriter, r *http.Request, lg *slog.Logger, subsID uuid.UUID, access auth.Access) bool {
+	if _, ok := auth.FromContext(r.Context()); !ok {
+		return true
+	}
+
+	ctx := r.Context()
+	if access != auth.AccessRead {
+		// перед изменением владелец проверяется по основной базе, а не по реплике
+		ctx = storage.WithPrimary(ctx)
+	}
+	subs, err := s.storage.ReadSubs(ctx, subsID)
+	if errors.Is(err, storage.ErrNotFound) {
+		lg.Info("subscription not found", "id", subsID)
+		subsNotFound(w, r)
+		return false
+	} else if err != nil {
+		lg.Error("failed to read subscription from storage", "id", subsID, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return false
+	}
+
+	if !visible(w, r, lg, subs) {
+		return false
+	}
+	if !allowed(r, subs.UserId, access) {
+		forbidden(w, r, lg)
+		return false
+	}
+	return true
+}
+
+// visible проверяет, что субъект может читать подписку. Чужая подписка
+// выглядит несуществующей: 403 вместо 404 подтверждал бы, что идентификатор
+// занят. При отказе ответ уже записан в w.
+func visible(w http.ResponseWriter, r *http.Request, lg *slog.Logger, subs *entity.Subscription) bool {
+	if allowed(r, subs.UserId, auth.AccessRead) {
+		return true
+	}
+	lg.Warn("access denied, subscription reported as not found", "id", subs.SubsID)
+	subsNotFound(w, r)
+	return false
+}
+
+func subsNotFound(w http.ResponseWriter, r *http.Request) {
+	problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeSubscriptionNotFound, "subscription not found"))
+}
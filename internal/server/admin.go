@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 500
+)
+
+func (s *Server) AdminListSubs(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "AdminListSubs")
+	lg.Info("received admin list subscriptions request")
+
+	limit := defaultAdminPageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxAdminPageSize {
+			lg.Warn("invalid limit", "limit", v)
+			problem.Write(w, r, problem.Validation(entity.FieldError{
+				Field: "limit", Code: entity.CodeInvalidRange, Message: "must be between 1 and 500",
+			}))
+			return
+		}
+		limit = n
+	}
+
+	offset := 0
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			lg.Warn("invalid offset", "offset", v)
+			problem.Write(w, r, problem.Validation(entity.FieldError{
+				Field: "offset", Code: entity.CodeInvalidRange, Message: "must be a non-negative integer",
+			}))
+			return
+		}
+		offset = n
+	}
+
+	subs, err := s.admin.ListAllSubs(r.Context(), limit, offset)
+	if err != nil {
+		lg.Error("failed to list subscriptions from storage", "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("subscriptions retrieved successfully", "count", len(subs), "limit", limit, "offset", offset)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(subs); err != nil {
+		lg.Error("failed to encode response", "err", err)
+		return
+	}
+}
+
+func (s *Server) AdminDeleteUserSubs(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "AdminDeleteUserSubs")
+
+	userID := chi.URLParam(r, "id")
+	lg.Info("received bulk delete subscriptions request", "user_id", userID)
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		lg.Error("failed to parse user id", "user_id", userID, "err", err)
+		problem.Write(w, r, problem.InvalidID("id"))
+		return
+	}
+
+	deleted, err := s.admin.DeleteUserSubs(r.Context(), id)
+	if err != nil {
+		lg.Error("failed to delete user subscriptions from storage", "user_id", id, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("user subscriptions deleted successfully", "user_id", id, "deleted", deleted)
+
+	s.markWritten(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(map[string]int64{"deleted": deleted}); err != nil {
+		lg.Error("failed to encode response", "err", err)
+		return
+	}
+}
+
+func (s *Server) AdminStats(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "AdminStats")
+	lg.Info("received usage statistics request")
+
+	stats, err := s.admin.Stats(r.Context())
+	if err != nil {
+		lg.Error("failed to collect statistics from storage", "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(stats); err != nil {
+		lg.Error("failed to encode response", "err", err)
+		return
+	}
+}
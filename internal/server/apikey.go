@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/auth"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type apiKeyResponse struct {
+	entity.APIKey
+	Key string `json:"key"`
+}
+
+func (s *Server) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "CreateAPIKey")
+	lg.Info("received create api key request")
+
+	var req entity.APIKeyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		lg.Error("failed to decode request body", "err", err)
+		problem.Write(w, r, problem.FromError(err))
+		return
+	}
+
+	key, err := entity.APIKeyToDataBase(lg, req, adminScope(r))
+	if err != nil {
+		lg.Error("failed to convert request to api key entity", "err", err)
+		problem.Write(w, r, problem.FromError(err))
+		return
+	}
+
+	token, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		lg.Error("failed to generate api key", "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+	key.Prefix = prefix
+
+	id, err := s.keys.CreateAPIKey(r.Context(), &key, hash)
+	if p := constraintProblem(err); p != nil {
+		lg.Info("request violates schema constraints", "err", err)
+		problem.Write(w, r, p)
+		return
+	} else if err != nil {
+		lg.Error("failed to create api key in storage", "name", key.Name, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("api key created successfully", "id", id, "name", key.Name, "scopes", key.Scopes)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err = json.NewEncoder(w).Encode(apiKeyResponse{APIKey: key, Key: token}); err != nil {
+		lg.Error("failed to encode response", "err", err)
+		return
+	}
+}
+
+func (s *Server) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "ListAPIKeys")
+	lg.Info("received list api keys request")
+
+	keys, err := s.keys.ListAPIKeys(r.Context())
+	if err != nil {
+		lg.Error("failed to list api keys from storage", "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("api keys retrieved successfully", "count", len(keys))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(keys); err != nil {
+		lg.Error("failed to encode response", "err", err)
+		return
+	}
+}
+
+func (s *Server) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "RevokeAPIKey")
+
+	keyID := chi.URLParam(r, "id")
+	lg.Info("received revoke api key request", "id", keyID)
+
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		lg.Error("failed to parse api key id", "id", keyID, "err", err)
+		problem.Write(w, r, problem.InvalidID("id"))
+		return
+	}
+
+	err = s.keys.RevokeAPIKey(r.Context(), id)
+	if errors.Is(err, storage.ErrAPIKeyNotFound) {
+		lg.Info("api key not found in storage", "id", id)
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeAPIKeyNotFound, "api key not found"))
+		return
+	} else if err != nil {
+		lg.Error("failed to revoke api key in storage", "id", id, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("api key revoked successfully", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "RotateAPIKey")
+
+	keyID := chi.URLParam(r, "id")
+	lg.Info("received rotate api key request", "id", keyID)
+
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		lg.Error("failed to parse api key id", "id", keyID, "err", err)
+		problem.Write(w, r, problem.InvalidID("id"))
+		return
+	}
+
+	token, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		lg.Error("failed to generate api key", "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	err = s.keys.RotateAPIKey(r.Context(), id, prefix, hash)
+	if errors.Is(err, storage.ErrAPIKeyNotFound) {
+		lg.Info("api key not found in storage", "id", id)
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeAPIKeyNotFound, "api key not found"))
+		return
+	} else if err != nil {
+		lg.Error("failed to rotate api key in storage", "id", id, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("api key rotated successfully", "id", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := map[string]string{"id": id.String(), "prefix": prefix, "key": token}
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		lg.Error("failed to encode response", "err", err)
+		return
+	}
+}
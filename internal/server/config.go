@@ -1,8 +1,120 @@
 package server
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/ratelimit"
+	"net"
+	"os"
+	"time"
+)
 
 type Config struct {
 	Port            string        `yaml:"port"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// DrainDelay — пауза между снятием готовности и остановкой приёма запросов,
+	// чтобы балансировщик успел исключить экземпляр.
+	DrainDelay        time.Duration    `yaml:"drain_delay"`
+	ReadTimeout       time.Duration    `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration    `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration    `yaml:"write_timeout"`
+	IdleTimeout       time.Duration    `yaml:"idle_timeout"`
+	MaxHeaderBytes    int              `yaml:"max_header_bytes"`
+	MaxBodyBytes      int64            `yaml:"max_body_bytes"`
+	TLS               TLSConfig        `yaml:"tls"`
+	RateLimit         ratelimit.Config `yaml:"rate_limit"`
+	// ReadYourWrites — сколько после записи чтения клиента идут в основную базу
+	// мимо реплик (cookie subs_read_primary); 0 отключает cookie.
+	ReadYourWrites time.Duration `yaml:"read_your_writes"`
+	Metrics        bool          `yaml:"metrics"`
+}
+
+// TLSConfig включает HTTPS, если заданы оба файла. ClientCAFile и ClientAuth
+// включают проверку клиентских сертификатов (mTLS).
+type TLSConfig struct {
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file"`
+	// ClientAuth — none, optional (сертификат проверяется, если предъявлен) или require.
+	ClientAuth string `yaml:"client_auth"`
+	// ReloadInterval — как часто проверять файлы на ротацию, по умолчанию 30s.
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.Port == "" {
+		errs = append(errs, errors.New("port is required"))
+	} else if _, _, err := net.SplitHostPort(c.Port); err != nil {
+		errs = append(errs, fmt.Errorf("port: %w", err))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown_timeout must be positive"))
+	}
+	if c.DrainDelay < 0 || (c.ShutdownTimeout > 0 && c.DrainDelay >= c.ShutdownTimeout) {
+		errs = append(errs, errors.New("drain_delay must be non-negative and less than shutdown_timeout"))
+	}
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"read_timeout", c.ReadTimeout},
+		{"read_header_timeout", c.ReadHeaderTimeout},
+		{"write_timeout", c.WriteTimeout},
+		{"idle_timeout", c.IdleTimeout},
+	}
+	for _, t := range timeouts {
+		if t.value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", t.name))
+		}
+	}
+	if c.ReadYourWrites < 0 {
+		errs = append(errs, errors.New("read_your_writes must not be negative"))
+	}
+	if c.MaxHeaderBytes < 0 {
+		errs = append(errs, errors.New("max_header_bytes must not be negative"))
+	}
+	if c.MaxBodyBytes < 0 {
+		errs = append(errs, errors.New("max_body_bytes must not be negative"))
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("tls.cert_file and tls.key_file must be set together"))
+	}
+	if c.TLS.Enabled() {
+		if _, err := os.Stat(c.TLS.CertFile); err != nil {
+			errs = append(errs, fmt.Errorf("tls.cert_file: %w", err))
+		}
+		if _, err := os.Stat(c.TLS.KeyFile); err != nil {
+			errs = append(errs, fmt.Errorf("tls.key_file: %w", err))
+		}
+	}
+	switch c.TLS.ClientAuth {
+	case "", ClientAuthNone:
+	case ClientAuthOptional, ClientAuthRequire:
+		if !c.TLS.Enabled() {
+			errs = append(errs, errors.New("tls.client_auth requires tls.cert_file and tls.key_file"))
+		}
+		if c.TLS.ClientCAFile == "" {
+			errs = append(errs, errors.New("tls.client_ca_file is required when tls.client_auth is set"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tls.client_auth must be one of %s, %s, %s",
+			ClientAuthNone, ClientAuthOptional, ClientAuthRequire))
+	}
+	if c.TLS.ClientCAFile != "" {
+		if _, err := os.Stat(c.TLS.ClientCAFile); err != nil {
+			errs = append(errs, fmt.Errorf("tls.client_ca_file: %w", err))
+		}
+	}
+	if c.TLS.ReloadInterval < 0 {
+		errs = append(errs, errors.New("tls.reload_interval must not be negative"))
+	}
+	if err := c.RateLimit.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("rate_limit: %w", err))
+	}
+	return errors.Join(errs...)
 }
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// HeaderReadPrimary со значением true направляет чтения запроса в основную базу.
+	HeaderReadPrimary = "X-Read-Primary"
+	// cookieReadPrimary выставляется после записи на server.read_your_writes,
+	// чтобы следующие чтения клиента не попали на отстающую реплику.
+	cookieReadPrimary = "subs_read_primary"
+)
+
+// readPrimary переключает чтения запроса на основную базу по заголовку
+// X-Read-Primary или по cookie, оставленной предыдущей записью.
+func readPrimary(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		force, _ := strconv.ParseBool(r.Header.Get(HeaderReadPrimary))
+		if !force {
+			_, err := r.Cookie(cookieReadPrimary)
+			force = err == nil
+		}
+		if force {
+			r = r.WithContext(storage.WithPrimary(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// markWritten выставляет cookie read-your-writes после успешной записи.
+// Вызывается до записи статуса ответа.
+func (s *Server) markWritten(w http.ResponseWriter) {
+	if s.cfg.ReadYourWrites <= 0 {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     cookieReadPrimary,
+		Value:    "1",
+		Path:     "/",
+		MaxAge:   max(int(s.cfg.ReadYourWrites.Round(time.Second)/time.Second), 1),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// decodeJSON разбирает тело запроса в v. Неизвестные поля и значения
+// неверного типа возвращаются как entity.ValidationErrors.
+func decodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err == nil {
+		if _, err = dec.Token(); !errors.Is(err, io.EOF) {
+			return errors.New("request body must contain a single JSON object")
+		}
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		return entity.ValidationErrors{{
+			Field:   typeErr.Field,
+			Code:    entity.CodeInvalidType,
+			Message: fmt.Sprintf("must be %s", typeErr.Type),
+		}}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return entity.ValidationErrors{{
+			Field:   field,
+			Code:    entity.CodeUnknownField,
+			Message: "is not allowed",
+		}}
+	}
+	return err
+}
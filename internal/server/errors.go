@@ -0,0 +1,20 @@
+package server
+
+import (
+	"errors"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"net/http"
+)
+
+// constraintProblem переводит нарушение ограничений схемы в ответ 400 или 409.
+// Для остальных ошибок возвращает nil.
+func constraintProblem(err error) *problem.Problem {
+	switch {
+	case errors.Is(err, storage.ErrConstraint):
+		return problem.New(http.StatusBadRequest, problem.CodeConstraintViolation, err.Error())
+	case errors.Is(err, storage.ErrConflict):
+		return problem.New(http.StatusConflict, problem.CodeConflict, err.Error())
+	}
+	return nil
+}
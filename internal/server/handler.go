@@ -3,45 +3,61 @@ package server
 import (
 	"encoding/json"
 	"errors"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/auth"
 	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
 	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"net/http"
-	"time"
 )
 
 func (s *Server) CreateSubs(w http.ResponseWriter, r *http.Request) {
-	lg := s.lg.With("handler", "CreateSubs")
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "CreateSubs")
 	lg.Info("received create subscription request")
 
 	var req entity.SubsRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(r, &req); err != nil {
 		lg.Error("failed to decode request body", "err", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Write(w, r, problem.FromError(err))
 		return
 	}
 
-	subs, err := entity.SubsToDataBase(lg, req)
+	loc, ok := s.ownerLocation(w, r, lg, req.UserId, auth.AccessWrite)
+	if !ok {
+		return
+	}
+
+	subs, err := entity.SubsToDataBase(lg, req, loc)
 	if err != nil {
 		lg.Error("failed to convert request to subscription entity", "err", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Write(w, r, problem.FromError(err))
 		return
 	}
 
 	id, err := s.storage.CreateSubs(r.Context(), &subs)
-	if err != nil {
+	if errors.Is(err, storage.ErrUserNotFound) {
+		lg.Info("user not found", "user_id", subs.UserId)
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeUserNotFound, "user not found"))
+		return
+	} else if p := constraintProblem(err); p != nil {
+		lg.Info("request violates schema constraints", "err", err)
+		problem.Write(w, r, p)
+		return
+	} else if err != nil {
 		lg.Error("failed to create subscription in storage",
 			"user_id", subs.UserId,
 			"service_name", subs.ServiceName,
 			"err", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, problem.Internal())
 		return
 	}
 
 	endDateStr := "nil"
 	if subs.EndDate != nil {
-		endDateStr = subs.EndDate.Format("2006-01")
+		endDateStr = subs.EndDate.Format(entity.DateLayout)
 	}
 
 	lg.Info("subscription created successfully",
@@ -49,23 +65,23 @@ func (s *Server) CreateSubs(w http.ResponseWriter, r *http.Request) {
 		"user_id", subs.UserId,
 		"service_name", subs.ServiceName,
 		"price", subs.Price,
-		"start_date", subs.StartDate.Format("2006-01"),
+		"start_date", subs.StartDate.Format(entity.DateLayout),
 		"end_date", endDateStr,
 	)
 
+	s.markWritten(w)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 
 	resp := map[string]string{"id": id.String()}
 	if err = json.NewEncoder(w).Encode(resp); err != nil {
 		lg.Error("failed to encode response", "err", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
 func (s *Server) ReadSubs(w http.ResponseWriter, r *http.Request) {
-	lg := s.lg.With("handler", "ReadSubs")
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "ReadSubs")
 
 	subsID := chi.URLParam(r, "id")
 	lg.Info("received read subscription request", "id", subsID)
@@ -73,23 +89,27 @@ func (s *Server) ReadSubs(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(subsID)
 	if err != nil {
 		lg.Error("failed to parse subscription id", "id", subsID, "err", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Write(w, r, problem.InvalidID("id"))
 		return
 	}
 	if id == uuid.Nil {
 		lg.Warn("subscription id is nil", "id", subsID)
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		problem.Write(w, r, problem.InvalidID("id"))
 		return
 	}
 
 	subs, err := s.storage.ReadSubs(r.Context(), id)
 	if errors.Is(err, storage.ErrNotFound) {
 		lg.Info("subscription not found", "id", id)
-		http.Error(w, "subscription not found", http.StatusNotFound)
+		subsNotFound(w, r)
 		return
 	} else if err != nil {
 		lg.Error("failed to read subscription from storage", "id", id, "err", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	if !visible(w, r, lg, subs) {
 		return
 	}
 
@@ -103,26 +123,30 @@ func (s *Server) ReadSubs(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(subs); err != nil {
 		lg.Error("failed to encode response", "id", subs.SubsID, "err", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
 func (s *Server) UpdateSubs(w http.ResponseWriter, r *http.Request) {
-	lg := s.lg.With("handler", "UpdateSubs")
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "UpdateSubs")
 	lg.Info("received update subscription request")
 
 	var req entity.SubsRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(r, &req); err != nil {
 		lg.Error("failed to decode request body", "err", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Write(w, r, problem.FromError(err))
+		return
+	}
+
+	loc, ok := s.ownerLocation(w, r, lg, req.UserId, auth.AccessWrite)
+	if !ok {
 		return
 	}
 
-	subs, err := entity.SubsToDataBase(lg, req)
+	subs, err := entity.SubsToDataBase(lg, req, loc)
 	if err != nil {
 		lg.Error("failed to convert request to subscription entity", "err", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Write(w, r, problem.FromError(err))
 		return
 	}
 
@@ -130,30 +154,42 @@ func (s *Server) UpdateSubs(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(subsID)
 	if err != nil {
 		lg.Error("failed to parse subscription id", "id", subsID, "err", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Write(w, r, problem.InvalidID("id"))
 		return
 	}
 	if id == uuid.Nil {
 		lg.Warn("subscription id is nil", "id", subsID)
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		problem.Write(w, r, problem.InvalidID("id"))
+		return
+	}
+
+	if !s.authorizeSubs(w, r, lg, id, auth.AccessWrite) {
 		return
 	}
 
 	err = s.storage.UpdateSubs(r.Context(), id, &subs)
 	if errors.Is(err, storage.ErrNotFound) {
 		lg.Info("subscription not found in storage", "id", id)
-		http.Error(w, "subscription not found", http.StatusNotFound)
+		subsNotFound(w, r)
+		return
+	} else if errors.Is(err, storage.ErrUserNotFound) {
+		lg.Info("user not found", "user_id", subs.UserId)
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeUserNotFound, "user not found"))
+		return
+	} else if p := constraintProblem(err); p != nil {
+		lg.Info("request violates schema constraints", "err", err)
+		problem.Write(w, r, p)
 		return
 	} else if err != nil {
 		lg.Error("failed to update subscription in storage", "id", id, "err", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		problem.Write(w, r, problem.Internal())
 		return
 	}
 
 	// безопасная обработка EndDate
 	endDateStr := "nil"
 	if subs.EndDate != nil {
-		endDateStr = subs.EndDate.Format("2006-01")
+		endDateStr = subs.EndDate.Format(entity.DateLayout)
 	}
 
 	lg.Info("subscription updated successfully",
@@ -161,15 +197,16 @@ func (s *Server) UpdateSubs(w http.ResponseWriter, r *http.Request) {
 		"user_id", subs.UserId,
 		"service_name", subs.ServiceName,
 		"price", subs.Price,
-		"start_date", subs.StartDate.Format("2006-01"),
+		"start_date", subs.StartDate.Format(entity.DateLayout),
 		"end_date", endDateStr,
 	)
 
+	s.markWritten(w)
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (s *Server) DeleteSubs(w http.ResponseWriter, r *http.Request) {
-	lg := s.lg.With("handler", "DeleteSubs")
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "DeleteSubs")
 
 	subsID := chi.URLParam(r, "id")
 	lg.Info("received delete subscription request", "id", subsID)
@@ -177,47 +214,72 @@ func (s *Server) DeleteSubs(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(subsID)
 	if err != nil {
 		lg.Error("failed to parse subscription id", "id", subsID, "err", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Write(w, r, problem.InvalidID("id"))
+		return
+	}
+
+	if !s.authorizeSubs(w, r, lg, id, auth.AccessDelete) {
 		return
 	}
 
 	err = s.storage.DeleteSubs(r.Context(), id)
 	if errors.Is(err, storage.ErrNotFound) {
 		lg.Info("subscription not found in storage", "id", id)
-		http.Error(w, "subscription not found", http.StatusNotFound)
+		subsNotFound(w, r)
 		return
 	} else if err != nil {
 		lg.Error("failed to delete subscription from storage", "id", id, "err", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		problem.Write(w, r, problem.Internal())
 		return
 	}
 
 	lg.Info("subscription deleted successfully", "id", id)
+	s.markWritten(w)
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (s *Server) ListSubs(w http.ResponseWriter, r *http.Request) {
-	lg := s.lg.With("handler", "ListSubs")
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "ListSubs")
 	lg.Info("received list subscriptions request")
 
 	date := r.URL.Query().Get("point_of_reference")
 	if date == "" {
 		lg.Warn("missing query parameter", "point_of_reference", date)
-		http.Error(w, "missing point_of_reference query parameter", http.StatusBadRequest)
+		problem.Write(w, r, problem.Validation(entity.FieldError{
+			Field: "point_of_reference", Code: entity.CodeRequired, Message: "query parameter is required",
+		}))
 		return
 	}
 
-	pointOfReference, err := time.Parse("01-2006", date)
+	loc, err := entity.LoadLocation(r.URL.Query().Get("timezone"), s.requesterLocation(r, lg))
+	if err != nil {
+		lg.Error("failed to load timezone", "timezone", r.URL.Query().Get("timezone"), "err", err)
+		problem.Write(w, r, problem.Validation(entity.FieldError{
+			Field: "timezone", Code: entity.CodeInvalidTimezone, Message: "must be an IANA time zone name",
+		}))
+		return
+	}
+
+	pointOfReference, err := entity.ParseDate(date, false, loc)
 	if err != nil {
 		lg.Error("failed to parse date", "date", date, "err", err)
-		http.Error(w, "error parsing start date", http.StatusBadRequest)
+		problem.Write(w, r, problem.Validation(entity.FieldError{
+			Field: "point_of_reference", Code: entity.CodeInvalidDate,
+			Message: "must be a date in YYYY-MM-DD or YYYY-MM format or an RFC 3339 timestamp",
+		}))
+		return
+	}
+
+	userID, ok := visibleUser(r)
+	if !ok {
+		forbidden(w, r, lg)
 		return
 	}
 
-	subs, err := s.storage.ListSubs(r.Context(), pointOfReference)
+	subs, err := s.storage.ListSubs(r.Context(), pointOfReference, userID)
 	if err != nil {
 		lg.Error("failed to list subscriptions from storage", "date", pointOfReference, "err", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		problem.Write(w, r, problem.Internal())
 		return
 	}
 
@@ -227,49 +289,59 @@ func (s *Server) ListSubs(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(subs); err != nil {
 		lg.Error("failed to encode response", "err", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
 func (s *Server) TotalCost(w http.ResponseWriter, r *http.Request) {
-	lg := s.lg.With("handler", "TotalCost")
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "TotalCost")
 	lg.Info("received total cost request")
 
+	// отдельный спан, чтобы отличать разбор запроса от запроса к базе
+	_, span := tracing.Tracer().Start(r.Context(), "decode TotalCostRequest")
 	var req entity.TotalCostRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(r, &req); err != nil {
+		span.End()
 		lg.Error("failed to decode request body", "err", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Write(w, r, problem.FromError(err))
+		return
+	}
+
+	loc, ok := s.ownerLocation(w, r, lg, req.UserId, auth.AccessRead)
+	if !ok {
+		span.End()
 		return
 	}
 
-	request, err := entity.TotalCostToDataBase(lg, req)
+	request, err := entity.TotalCostToDataBase(lg, req, loc)
+	span.End()
 	if err != nil {
 		lg.Error("failed to convert request to database model", "err", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		problem.Write(w, r, problem.FromError(err))
 		return
 	}
 
 	totalCost, err := s.storage.TotalCost(r.Context(), request)
 	if err != nil {
 		lg.Error("failed to calculate total cost from storage", "user_id", request.UserId, "service_name", request.ServiceName, "err", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, problem.Internal())
 		return
 	}
 
 	lg.Info("total cost calculated successfully",
 		"user_id", request.UserId,
 		"service_name", request.ServiceName,
-		"date1", request.Date1.Format("2006-01"),
-		"date2", request.Date2.Format("2006-01"),
-		"total_cost", totalCost,
+		"date1", request.Date1.Format(entity.DateLayout),
+		"date2", request.Date2.Format(entity.DateLayout),
+		"proration", request.Proration,
+		"paid_by", totalCost.PaidBy,
+		"fair_share", totalCost.FairShare,
 	)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(totalCost); err != nil {
 		lg.Error("failed to encode response", "err", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
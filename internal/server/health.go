@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const readinessTimeout = 2 * time.Second
+
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+	PendingMigrations(ctx context.Context) (int, error)
+}
+
+type checkResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+}
+
+// Healthz — проверка живости: процесс запущен и обрабатывает запросы.
+func (s *Server) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// Readyz — проверка готовности: база отвечает, миграции применены
+// и сервер не находится в процессе остановки.
+func (s *Server) Readyz(w http.ResponseWriter, r *http.Request) {
+	lg := s.lg.With("handler", "Readyz")
+
+	checks := make(map[string]checkResult, 3)
+	ready := true
+	check := func(name string, err error) {
+		if err != nil {
+			ready = false
+			checks[name] = checkResult{Status: "fail", Error: err.Error()}
+			return
+		}
+		checks[name] = checkResult{Status: "ok"}
+	}
+
+	if s.shuttingDown.Load() {
+		check("shutdown", fmt.Errorf("server is shutting down"))
+	} else {
+		check("shutdown", nil)
+	}
+
+	if s.health != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+		check("database", s.health.Ping(ctx))
+
+		pending, err := s.health.PendingMigrations(ctx)
+		if err == nil && pending > 0 {
+			err = fmt.Errorf("%d pending migrations", pending)
+		}
+		check("migrations", err)
+	}
+
+	resp := healthResponse{Status: "ok", Checks: checks}
+	status := http.StatusOK
+	if !ready {
+		lg.Warn("service is not ready", "checks", checks)
+		resp.Status = "fail"
+		status = http.StatusServiceUnavailable
+	}
+	writeHealth(w, status, resp)
+}
+
+func writeHealth(w http.ResponseWriter, status int, resp healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/auth"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+func (s *Server) AddMember(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "AddMember")
+
+	subsID := chi.URLParam(r, "id")
+	lg.Info("received add member request", "id", subsID)
+
+	id, err := uuid.Parse(subsID)
+	if err != nil || id == uuid.Nil {
+		lg.Error("failed to parse subscription id", "id", subsID, "err", err)
+		problem.Write(w, r, problem.InvalidID("id"))
+		return
+	}
+
+	var req entity.MemberRequest
+	if err = decodeJSON(r, &req); err != nil {
+		lg.Error("failed to decode request body", "err", err)
+		problem.Write(w, r, problem.FromError(err))
+		return
+	}
+
+	member, err := entity.MemberToDataBase(lg, id, req)
+	if err != nil {
+		lg.Error("failed to convert request to member entity", "err", err)
+		problem.Write(w, r, problem.FromError(err))
+		return
+	}
+
+	if !s.authorizeSubs(w, r, lg, id, auth.AccessWrite) {
+		return
+	}
+
+	err = s.members.AddMember(r.Context(), &member)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		lg.Info("subscription not found", "id", id)
+		subsNotFound(w, r)
+		return
+	case errors.Is(err, storage.ErrUserNotFound):
+		lg.Info("user not found", "user_id", member.UserId)
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeUserNotFound, "user not found"))
+		return
+	case errors.Is(err, storage.ErrMemberIsPayer):
+		lg.Info("member is the subscription payer", "user_id", member.UserId)
+		problem.Write(w, r, problem.New(http.StatusConflict, problem.CodeMemberIsPayer, err.Error()))
+		return
+	case constraintProblem(err) != nil:
+		lg.Info("request violates schema constraints", "err", err)
+		problem.Write(w, r, constraintProblem(err))
+		return
+	case err != nil:
+		lg.Error("failed to add member in storage", "id", id, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("member added successfully", "id", id, "user_id", member.UserId, "split", member.Split)
+	s.markWritten(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) ListMembers(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "ListMembers")
+
+	subsID := chi.URLParam(r, "id")
+	lg.Info("received list members request", "id", subsID)
+
+	id, err := uuid.Parse(subsID)
+	if err != nil || id == uuid.Nil {
+		lg.Error("failed to parse subscription id", "id", subsID, "err", err)
+		problem.Write(w, r, problem.InvalidID("id"))
+		return
+	}
+
+	if !s.authorizeSubs(w, r, lg, id, auth.AccessRead) {
+		return
+	}
+
+	members, err := s.members.ListMembers(r.Context(), id)
+	if err != nil {
+		lg.Error("failed to list members from storage", "id", id, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("members retrieved successfully", "id", id, "count", len(members))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(members); err != nil {
+		lg.Error("failed to encode response", "err", err)
+		return
+	}
+}
+
+func (s *Server) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "RemoveMember")
+
+	subsID := chi.URLParam(r, "id")
+	userID := chi.URLParam(r, "userId")
+	lg.Info("received remove member request", "id", subsID, "user_id", userID)
+
+	id, err := uuid.Parse(subsID)
+	if err != nil {
+		lg.Error("failed to parse subscription id", "id", subsID, "err", err)
+		problem.Write(w, r, problem.InvalidID("id"))
+		return
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		lg.Error("failed to parse user id", "user_id", userID, "err", err)
+		problem.Write(w, r, problem.InvalidID("userId"))
+		return
+	}
+
+	// участник может сам выйти из подписки, остальными управляет владелец
+	if !allowed(r, uid, auth.AccessWrite) && !s.authorizeSubs(w, r, lg, id, auth.AccessWrite) {
+		return
+	}
+
+	err = s.members.RemoveMember(r.Context(), id, uid)
+	if errors.Is(err, storage.ErrMemberNotFound) {
+		lg.Info("member not found in storage", "id", id, "user_id", uid)
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeMemberNotFound, "member not found"))
+		return
+	} else if err != nil {
+		lg.Error("failed to remove member from storage", "id", id, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("member removed successfully", "id", id, "user_id", uid)
+	s.markWritten(w)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,10 +3,19 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/auth"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/metrics"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/ratelimit"
 	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tracing"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"log/slog"
 	"net/http"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,33 +23,125 @@ type Server struct {
 	lg      *slog.Logger
 	srv     *http.Server
 	storage storage.SubscriptionStorage
+	users   storage.UserStorage
+	members storage.MemberStorage
+	keys    storage.APIKeyStorage
+	admin   storage.AdminStorage
+	health  HealthChecker
+	cfg     Config
+
+	shuttingDown atomic.Bool
 }
 
-func New(log *slog.Logger, addr string, stor storage.SubscriptionStorage) *Server {
+func New(
+	log *slog.Logger,
+	cfg Config,
+	stor storage.SubscriptionStorage,
+	users storage.UserStorage,
+	members storage.MemberStorage,
+	keys storage.APIKeyStorage,
+	admin storage.AdminStorage,
+	mon *metrics.Metrics,
+	health HealthChecker,
+	middlewares ...func(http.Handler) http.Handler,
+) *Server {
 	lg := log.With("module", "server")
-	lg.Info("initializing server", "addr", addr)
+	lg.Info("initializing server", "addr", cfg.Port)
 
 	s := &Server{
 		lg:      lg,
-		storage: stor,
+		storage: tracing.InstrumentStorage(stor),
+		users:   users,
+		members: members,
+		keys:    keys,
+		admin:   admin,
+		health:  health,
+		cfg:     cfg,
+	}
+
+	limit := func(group string) func(http.Handler) http.Handler {
+		return ratelimit.Middleware(lg, cfg.RateLimit, group)
 	}
 
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID, requestIDHeader)
+	if cfg.MaxBodyBytes > 0 {
+		r.Use(limitBody(cfg.MaxBodyBytes))
+	}
+	r.Use(tracing.Middleware, readPrimary)
+	if mon != nil {
+		lg.Info("metrics enabled", "path", "/metrics")
+		r.Use(mon.Middleware)
+		r.Method(http.MethodGet, "/metrics", mon.Handler())
+		s.storage = mon.InstrumentStorage(s.storage)
+	}
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeNotFound, "route not found"))
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, r, problem.New(http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, ""))
+	})
+	r.Get("/healthz", s.Healthz)
+	r.Get("/readyz", s.Readyz)
 	r.Route("/api", func(r chi.Router) {
 		r.Route("/v1", func(r chi.Router) {
+			// лимит по IP стоит до аутентификации, лимиты групп — после неё
+			r.Use(ratelimit.IPMiddleware(lg, cfg.RateLimit))
+			r.Use(middlewares...)
 			lg.Info("registering API routes")
-			r.Post("/subs", s.CreateSubs)
-			r.Get("/subs/{id}", s.ReadSubs)
-			r.Post("/subs/{id}", s.UpdateSubs)
-			r.Delete("/subs/{id}", s.DeleteSubs)
-			r.Get("/subs", s.ListSubs)
-			r.Post("/cost", s.TotalCost)
+
+			r.Group(func(r chi.Router) {
+				r.Use(limit("subs"))
+				read := auth.RequireScope(entity.ScopeSubsRead)
+				write := auth.RequireScope(entity.ScopeSubsWrite)
+				r.With(write).Post("/subs", s.CreateSubs)
+				r.With(read).Get("/subs/{id}", s.ReadSubs)
+				r.With(write).Post("/subs/{id}", s.UpdateSubs)
+				r.With(write).Delete("/subs/{id}", s.DeleteSubs)
+				r.With(read).Get("/subs", s.ListSubs)
+				r.With(read).Get("/subs/{id}/members", s.ListMembers)
+				r.With(write).Post("/subs/{id}/members", s.AddMember)
+				r.With(write).Delete("/subs/{id}/members/{userId}", s.RemoveMember)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(limit("users"))
+				subsRead := auth.RequireScope(entity.ScopeSubsRead)
+				usersRead := auth.RequireScope(entity.ScopeUsersRead)
+				usersWrite := auth.RequireScope(entity.ScopeUsersWrite)
+				r.With(usersWrite, auth.Require(auth.PermManage)).Post("/users", s.CreateUser)
+				r.With(usersRead).Get("/users/{id}", s.ReadUser)
+				r.With(usersWrite).Post("/users/{id}", s.UpdateUser)
+				r.With(usersWrite).Delete("/users/{id}", s.DeleteUser)
+				r.With(usersRead, auth.Require(auth.PermReadAny)).Get("/users", s.ListUsers)
+				r.With(usersRead, subsRead).Get("/users/{id}/subs", s.ListUserSubs)
+			})
+
+			r.With(limit("cost"), auth.RequireScope(entity.ScopeCostRead)).Post("/cost", s.TotalCost)
+
+			r.Group(func(r chi.Router) {
+				r.Use(limit("admin"), auth.Require(auth.PermManage))
+				r.Post("/keys", s.CreateAPIKey)
+				r.Get("/keys", s.ListAPIKeys)
+				r.Delete("/keys/{id}", s.RevokeAPIKey)
+				r.Post("/keys/{id}/rotate", s.RotateAPIKey)
+
+				r.Get("/admin/subs", s.AdminListSubs)
+				r.Delete("/admin/users/{id}/subs", s.AdminDeleteUserSubs)
+				r.Get("/admin/stats", s.AdminStats)
+			})
 		})
 	})
 
 	s.srv = &http.Server{
-		Addr:    addr,
-		Handler: r,
+		Addr:              cfg.Port,
+		Handler:           r,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(lg.Handler(), slog.LevelWarn),
 	}
 
 	lg.Info("server initialized successfully")
@@ -48,9 +149,22 @@ func New(log *slog.Logger, addr string, stor storage.SubscriptionStorage) *Serve
 }
 
 func (s *Server) Run() error {
-	s.lg.Info("starting server", "addr", s.srv.Addr)
-
-	err := s.srv.ListenAndServe()
+	var err error
+	if s.cfg.TLS.Enabled() {
+		var certs *certReloader
+		certs, err = newCertReloader(s.lg, s.cfg.TLS)
+		if err != nil {
+			s.lg.Error("failed to load tls certificates", "err", err)
+			return fmt.Errorf("tls: %w", err)
+		}
+		s.srv.TLSConfig = certs.tlsConfig()
+		s.lg.Info("starting server", "addr", s.srv.Addr, "tls", true, "client_auth", s.cfg.TLS.ClientAuth)
+		// сертификат выдаёт TLSConfig, поэтому пути не передаются
+		err = s.srv.ListenAndServeTLS("", "")
+	} else {
+		s.lg.Info("starting server", "addr", s.srv.Addr, "tls", false)
+		err = s.srv.ListenAndServe()
+	}
 	if errors.Is(err, http.ErrServerClosed) {
 		s.lg.Info("server stopped gracefully")
 		return nil
@@ -63,18 +177,66 @@ func (s *Server) Run() error {
 	return nil
 }
 
+// ShutDown останавливает сервер за ShutdownTimeout: снимает готовность, ждёт
+// DrainDelay и дожидается завершения текущих запросов. Остальные компоненты
+// останавливает lifecycle.Manager после сервера.
 func (s *Server) ShutDown() error {
-	s.lg.Info("shutting down server", "timeout_sec", 3)
+	// готовность снимается сразу, чтобы балансировщик перестал слать запросы
+	s.shuttingDown.Store(true)
+	s.lg.Info("shutting down server",
+		"timeout", s.cfg.ShutdownTimeout,
+		"drain_delay", s.cfg.DrainDelay,
+	)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
 	defer cancel()
 
-	err := s.srv.Shutdown(ctx)
-	if err != nil {
-		s.lg.Error("server shutdown failed", "err", err)
-		return err
+	if s.cfg.DrainDelay > 0 {
+		select {
+		case <-time.After(s.cfg.DrainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	var errs []error
+	if err := s.srv.Shutdown(ctx); err != nil {
+		s.lg.Error("server shutdown failed, closing remaining connections", "err", err)
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+		if err = s.srv.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close http server: %w", err))
+		}
+	} else {
+		s.lg.Info("in-flight requests drained")
 	}
 
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
 	s.lg.Info("server shutdown completed successfully")
 	return nil
 }
+
+// requestIDHeader возвращает клиенту идентификатор запроса, чтобы его можно
+// было сопоставить с логами и полем requestId в ответах об ошибках.
+func requestIDHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(r.Context()))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitBody ограничивает размер тела запроса. Превышение обнаруживается
+// при чтении тела и возвращается клиенту как 413.
+func limitBody(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				problem.Write(w, r, problem.New(http.StatusRequestEntityTooLarge, problem.CodeRequestTooLarge,
+					fmt.Sprintf("request body must not exceed %d bytes", n)))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/auth"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// userLocation возвращает часовой пояс пользователя userID. Если идентификатор
+// некорректен или пользователь не найден, используется UTC: ошибку в запросе
+// сообщит проверка полей.
+func (s *Server) userLocation(ctx context.Context, lg *slog.Logger, userID string) *time.Location {
+	id, err := uuid.Parse(userID)
+	if err != nil || id == uuid.Nil {
+		return time.UTC
+	}
+
+	user, err := s.users.ReadUser(ctx, id)
+	if err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) {
+			lg.Warn("failed to read user timezone, falling back to UTC", "user_id", id, "err", err)
+		}
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		lg.Warn("user has unknown timezone, falling back to UTC", "user_id", id, "timezone", user.Timezone)
+		return time.UTC
+	}
+	return loc
+}
+
+// requesterLocation возвращает часовой пояс пользователя, выполняющего запрос,
+// или UTC для сервисных клиентов и при отключённой аутентификации.
+func (s *Server) requesterLocation(r *http.Request, lg *slog.Logger) *time.Location {
+	id, ok := auth.FromContext(r.Context())
+	if !ok || id.Service {
+		return time.UTC
+	}
+	return s.userLocation(r.Context(), lg, id.UserID.String())
+}
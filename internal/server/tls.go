@@ -0,0 +1,168 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional"
+	ClientAuthRequire  = "require"
+)
+
+const defaultReloadInterval = 30 * time.Second
+
+// certReloader отдаёт текущий сертификат сервера и пул CA клиентов. Раз в
+// interval при очередном рукопожатии проверяется время изменения файлов, и
+// после ротации они перечитываются без перезапуска. Если новые файлы не
+// читаются, продолжает использоваться прежний сертификат.
+type certReloader struct {
+	lg       *slog.Logger
+	cfg      TLSConfig
+	interval time.Duration
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime map[string]time.Time
+	checked time.Time
+}
+
+func newCertReloader(lg *slog.Logger, cfg TLSConfig) (*certReloader, error) {
+	interval := cfg.ReloadInterval
+	if interval == 0 {
+		interval = defaultReloadInterval
+	}
+	c := &certReloader{
+		lg:       lg.With("module", "tls"),
+		cfg:      cfg,
+		interval: interval,
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *certReloader) files() []string {
+	files := []string{c.cfg.CertFile, c.cfg.KeyFile}
+	if c.cfg.ClientCAFile != "" {
+		files = append(files, c.cfg.ClientCAFile)
+	}
+	return files
+}
+
+// load читает все файлы и подменяет сертификат и пул CA. Вызывается под c.mu
+// или до начала работы сервера.
+func (c *certReloader) load() error {
+	modTime := make(map[string]time.Time, 3)
+	for _, f := range c.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", f, err)
+		}
+		modTime[f] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.cfg.CertFile, c.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if c.cfg.ClientCAFile != "" {
+		data, err := os.ReadFile(c.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client ca file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return errors.New("client ca file contains no PEM certificates")
+		}
+	}
+
+	c.cert = &cert
+	c.pool = pool
+	c.modTime = modTime
+	c.checked = time.Now()
+	return nil
+}
+
+// changed сообщает, изменился ли хотя бы один файл с момента последней загрузки.
+func (c *certReloader) changed() bool {
+	for _, f := range c.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			// файл может отсутствовать в момент ротации, проверим позже
+			return false
+		}
+		if !info.ModTime().Equal(c.modTime[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *certReloader) maybeReload() {
+	c.mu.RLock()
+	due := time.Since(c.checked) >= c.interval
+	c.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.checked) < c.interval {
+		return
+	}
+	c.checked = time.Now()
+	if !c.changed() {
+		return
+	}
+	if err := c.load(); err != nil {
+		c.lg.Error("failed to reload tls certificates, keeping previous ones", "err", err)
+		return
+	}
+	c.lg.Info("tls certificates reloaded", "cert_file", c.cfg.CertFile, "client_ca_file", c.cfg.ClientCAFile)
+}
+
+func (c *certReloader) current() (*tls.Certificate, *x509.CertPool) {
+	c.maybeReload()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, c.pool
+}
+
+// tlsConfig собирает конфигурацию TLS-сервера. Сертификат и пул CA берутся
+// из reloader при каждом рукопожатии.
+func (c *certReloader) tlsConfig() *tls.Config {
+	clientAuth := tls.NoClientCert
+	switch c.cfg.ClientAuth {
+	case ClientAuthOptional:
+		clientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: clientAuth,
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cert, pool := c.current()
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.Certificates = []tls.Certificate{*cert}
+		cfg.ClientCAs = pool
+		return cfg, nil
+	}
+	return base
+}
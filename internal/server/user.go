@@ -0,0 +1,251 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/auth"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+func (s *Server) CreateUser(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "CreateUser")
+	lg.Info("received create user request")
+
+	var req entity.UserRequest
+	if err := decodeJSON(r, &req); err != nil {
+		lg.Error("failed to decode request body", "err", err)
+		problem.Write(w, r, problem.FromError(err))
+		return
+	}
+
+	if req.Currency == "" {
+		req.Currency = tenant.SettingsFromContext(r.Context()).DefaultCurrency
+	}
+
+	user, err := entity.UserToDataBase(lg, req)
+	if err != nil {
+		lg.Error("failed to convert request to user entity", "err", err)
+		problem.Write(w, r, problem.FromError(err))
+		return
+	}
+
+	id, err := s.users.CreateUser(r.Context(), &user)
+	if errors.Is(err, storage.ErrEmailTaken) {
+		lg.Info("email already in use", "email", user.Email)
+		problem.Write(w, r, problem.New(http.StatusConflict, problem.CodeEmailTaken, "email already in use"))
+		return
+	} else if p := constraintProblem(err); p != nil {
+		lg.Info("request violates schema constraints", "err", err)
+		problem.Write(w, r, p)
+		return
+	} else if err != nil {
+		lg.Error("failed to create user in storage", "email", user.Email, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("user created successfully", "id", id, "email", user.Email)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	resp := map[string]string{"id": id.String()}
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		lg.Error("failed to encode response", "err", err)
+		return
+	}
+}
+
+func (s *Server) ReadUser(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "ReadUser")
+
+	userID := chi.URLParam(r, "id")
+	lg.Info("received read user request", "id", userID)
+
+	id, err := uuid.Parse(userID)
+	if err != nil || id == uuid.Nil {
+		lg.Error("failed to parse user id", "id", userID, "err", err)
+		problem.Write(w, r, problem.InvalidID("id"))
+		return
+	}
+
+	if !allowed(r, id, auth.AccessRead) {
+		forbidden(w, r, lg)
+		return
+	}
+
+	user, err := s.users.ReadUser(r.Context(), id)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		lg.Info("user not found", "id", id)
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeUserNotFound, "user not found"))
+		return
+	} else if err != nil {
+		lg.Error("failed to read user from storage", "id", id, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("user retrieved successfully", "id", user.UserId)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(user); err != nil {
+		lg.Error("failed to encode response", "id", user.UserId, "err", err)
+		return
+	}
+}
+
+func (s *Server) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "UpdateUser")
+	lg.Info("received update user request")
+
+	var req entity.UserRequest
+	if err := decodeJSON(r, &req); err != nil {
+		lg.Error("failed to decode request body", "err", err)
+		problem.Write(w, r, problem.FromError(err))
+		return
+	}
+
+	user, err := entity.UserToDataBase(lg, req)
+	if err != nil {
+		lg.Error("failed to convert request to user entity", "err", err)
+		problem.Write(w, r, problem.FromError(err))
+		return
+	}
+
+	userID := chi.URLParam(r, "id")
+	id, err := uuid.Parse(userID)
+	if err != nil || id == uuid.Nil {
+		lg.Error("failed to parse user id", "id", userID, "err", err)
+		problem.Write(w, r, problem.InvalidID("id"))
+		return
+	}
+
+	if !allowed(r, id, auth.AccessWrite) {
+		forbidden(w, r, lg)
+		return
+	}
+
+	err = s.users.UpdateUser(r.Context(), id, &user)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		lg.Info("user not found in storage", "id", id)
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeUserNotFound, "user not found"))
+		return
+	} else if errors.Is(err, storage.ErrEmailTaken) {
+		lg.Info("email already in use", "email", user.Email)
+		problem.Write(w, r, problem.New(http.StatusConflict, problem.CodeEmailTaken, "email already in use"))
+		return
+	} else if p := constraintProblem(err); p != nil {
+		lg.Info("request violates schema constraints", "err", err)
+		problem.Write(w, r, p)
+		return
+	} else if err != nil {
+		lg.Error("failed to update user in storage", "id", id, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("user updated successfully", "id", id, "email", user.Email)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "DeleteUser")
+
+	userID := chi.URLParam(r, "id")
+	lg.Info("received delete user request", "id", userID)
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		lg.Error("failed to parse user id", "id", userID, "err", err)
+		problem.Write(w, r, problem.InvalidID("id"))
+		return
+	}
+
+	if !allowed(r, id, auth.AccessDelete) {
+		forbidden(w, r, lg)
+		return
+	}
+
+	err = s.users.DeleteUser(r.Context(), id)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		lg.Info("user not found in storage", "id", id)
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeUserNotFound, "user not found"))
+		return
+	} else if err != nil {
+		lg.Error("failed to delete user from storage", "id", id, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("user deleted successfully", "id", id)
+	s.markWritten(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) ListUsers(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "ListUsers")
+	lg.Info("received list users request")
+
+	users, err := s.users.ListUsers(r.Context())
+	if err != nil {
+		lg.Error("failed to list users from storage", "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("users retrieved successfully", "count", len(users))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(users); err != nil {
+		lg.Error("failed to encode response", "err", err)
+		return
+	}
+}
+
+func (s *Server) ListUserSubs(w http.ResponseWriter, r *http.Request) {
+	lg := logger.WithTrace(r.Context(), s.lg).With("handler", "ListUserSubs")
+
+	userID := chi.URLParam(r, "id")
+	lg.Info("received list user subscriptions request", "id", userID)
+
+	id, err := uuid.Parse(userID)
+	if err != nil || id == uuid.Nil {
+		lg.Error("failed to parse user id", "id", userID, "err", err)
+		problem.Write(w, r, problem.InvalidID("id"))
+		return
+	}
+
+	if !allowed(r, id, auth.AccessRead) {
+		forbidden(w, r, lg)
+		return
+	}
+
+	subs, err := s.users.ListUserSubs(r.Context(), id)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		lg.Info("user not found", "id", id)
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CodeUserNotFound, "user not found"))
+		return
+	} else if err != nil {
+		lg.Error("failed to list user subscriptions from storage", "id", id, "err", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	lg.Info("user subscriptions retrieved successfully", "id", id, "count", len(subs))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err = json.NewEncoder(w).Encode(subs); err != nil {
+		lg.Error("failed to encode response", "err", err)
+		return
+	}
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
+	"github.com/google/uuid"
+)
+
+type AdminStorage interface {
+	ListAllSubs(ctx context.Context, limit int, offset int) ([]entity.Subscription, error)
+	DeleteUserSubs(ctx context.Context, userID uuid.UUID) (int64, error)
+	Stats(ctx context.Context) (entity.Stats, error)
+}
+
+// ownerToday — текущая дата в часовом поясе владельца подписки. Выражение
+// ожидает, что таблица users подключена в запросе под алиасом u.
+const ownerToday = `(now() AT TIME ZONE COALESCE(u.timezone, 'UTC'))::date`
+
+func (s *Storage) ListAllSubs(ctx context.Context, limit int, offset int) ([]entity.Subscription, error) {
+	subs := make([]entity.Subscription, 0, limit)
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT
+            subscriptionId,
+            serviceName,
+            price,
+            userID,
+            startDate,
+            endDate
+        FROM subscription
+        WHERE tenantId = $1
+        ORDER BY startDate DESC, subscriptionId
+        LIMIT $2 OFFSET $3
+    `, tenant.FromContext(ctx), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("listing all subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sub entity.Subscription
+		err = rows.Scan(
+			&sub.SubsID,
+			&sub.ServiceName,
+			&sub.Price,
+			&sub.UserId,
+			&sub.StartDate,
+			&sub.EndDate,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription row: %w", err)
+		}
+
+		subs = append(subs, sub)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return subs, nil
+}
+
+func (s *Storage) DeleteUserSubs(ctx context.Context, userID uuid.UUID) (int64, error) {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "DeleteUserSubs")
+	lg.Info("deleting all subscriptions of user", "user_id", userID)
+
+	r, err := s.pool.Exec(ctx,
+		`DELETE FROM subscription WHERE userID = $1 AND tenantId = $2`, userID, tenant.FromContext(ctx))
+	if err != nil {
+		lg.Error("failed to execute delete query", "user_id", userID, "err", err)
+		return 0, fmt.Errorf("deleting user subscriptions: %w", err)
+	}
+
+	rows := r.RowsAffected()
+	lg.Info("user subscriptions deleted successfully", "user_id", userID, "deleted", rows)
+	return rows, nil
+}
+
+// Stats собирает статистику использования по арендатору. Активной считается
+// подписка, действующая на текущую дату; выручка — сумма цен активных подписок.
+func (s *Storage) Stats(ctx context.Context) (entity.Stats, error) {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "Stats")
+	lg.Info("collecting usage statistics")
+
+	tenantID := tenant.FromContext(ctx)
+	stats := entity.Stats{Services: make([]entity.ServiceStats, 0)}
+
+	err := s.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM users WHERE tenantId = $1`, tenantID).Scan(&stats.Users)
+	if err != nil {
+		lg.Error("failed to count users", "err", err)
+		return entity.Stats{}, fmt.Errorf("counting users: %w", err)
+	}
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT
+            serviceName,
+            COUNT(*),
+            COUNT(*) FILTER (WHERE active),
+            COALESCE(SUM(price) FILTER (WHERE active), 0)
+        FROM (
+            SELECT s.serviceName, s.price,
+                   s.startDate <= `+ownerToday+` AND (s.endDate IS NULL OR s.endDate >= `+ownerToday+`) AS active
+            FROM subscription s
+            LEFT JOIN users u ON u.userId = s.userID AND u.tenantId = s.tenantId
+            WHERE s.tenantId = $1
+        ) s
+        GROUP BY serviceName
+        ORDER BY serviceName
+    `, tenantID)
+	if err != nil {
+		lg.Error("failed to query service statistics", "err", err)
+		return entity.Stats{}, fmt.Errorf("service statistics: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var svc entity.ServiceStats
+		err = rows.Scan(&svc.ServiceName, &svc.Subscriptions, &svc.ActiveSubscriptions, &svc.MonthlyRevenue)
+		if err != nil {
+			return entity.Stats{}, fmt.Errorf("failed to scan service statistics row: %w", err)
+		}
+
+		stats.Subscriptions += svc.Subscriptions
+		stats.ActiveSubscriptions += svc.ActiveSubscriptions
+		stats.MonthlyRevenue += svc.MonthlyRevenue
+		stats.Services = append(stats.Services, svc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return entity.Stats{}, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	lg.Info("usage statistics collected successfully",
+		"users", stats.Users,
+		"subscriptions", stats.Subscriptions,
+		"active", stats.ActiveSubscriptions,
+	)
+	return stats, nil
+}
+
+// CountActiveSubs считает подписки, действующие на текущую дату владельца, по всем арендаторам.
+// Используется для метрик и не ограничивается арендатором запроса.
+func (s *Storage) CountActiveSubs(ctx context.Context) (map[string]int, error) {
+	rows, err := s.pool.Query(withAllTenants(ctx), `
+        SELECT s.tenantId, COUNT(*)
+        FROM subscription s
+        LEFT JOIN users u ON u.userId = s.userID AND u.tenantId = s.tenantId
+        WHERE s.startDate <= `+ownerToday+` AND (s.endDate IS NULL OR s.endDate >= `+ownerToday+`)
+        GROUP BY s.tenantId
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("counting active subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var tenantID string
+		var n int
+		if err = rows.Scan(&tenantID, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan active subscriptions row: %w", err)
+		}
+		counts[tenantID] = n
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"strings"
+)
+
+type APIKeyStorage interface {
+	CreateAPIKey(ctx context.Context, key *entity.APIKey, hash string) (uuid.UUID, error)
+	ListAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID uuid.UUID) error
+	RotateAPIKey(ctx context.Context, keyID uuid.UUID, prefix string, hash string) error
+	FindAPIKey(ctx context.Context, hash string) (*entity.APIKey, error)
+}
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+const apiKeyColumns = `keyId, tenantId, name, prefix, array_to_string(scopes, ' '), createdAt, revokedAt`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*entity.APIKey, error) {
+	var key entity.APIKey
+	var scopes string
+
+	if err := row.Scan(&key.KeyId, &key.TenantId, &key.Name, &key.Prefix, &scopes, &key.CreatedAt, &key.RevokedAt); err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Fields(scopes)
+	return &key, nil
+}
+
+func (s *Storage) CreateAPIKey(ctx context.Context, key *entity.APIKey, hash string) (uuid.UUID, error) {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "CreateAPIKey")
+	lg.Info("creating api key in database", "name", key.Name, "scopes", key.Scopes)
+
+	key.TenantId = tenant.FromContext(ctx)
+	err := s.pool.QueryRow(ctx,
+		`INSERT INTO api_key(name, prefix, hash, scopes, tenantId)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING keyId, createdAt`,
+		key.Name, key.Prefix, hash, key.Scopes, key.TenantId,
+	).Scan(&key.KeyId, &key.CreatedAt)
+	if err != nil {
+		if cerr := asConstraintError(err); cerr != nil {
+			lg.Info("constraint violated", "err", cerr)
+			return uuid.Nil, cerr
+		}
+		lg.Error("failed to create api key in database", "err", err)
+		return uuid.Nil, fmt.Errorf("create api key: %w", err)
+	}
+
+	lg.Info("api key created successfully", "key_id", key.KeyId)
+	return key.KeyId, nil
+}
+
+func (s *Storage) ListAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	keys := make([]entity.APIKey, 0)
+
+	rows, err := s.pool.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_key WHERE tenantId = $1 ORDER BY createdAt`,
+		tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("listing api keys: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key row: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (s *Storage) RevokeAPIKey(ctx context.Context, keyID uuid.UUID) error {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "RevokeAPIKey")
+	lg.Info("revoking api key", "key_id", keyID)
+
+	r, err := s.pool.Exec(ctx,
+		`UPDATE api_key SET revokedAt = now() WHERE keyId = $1 AND tenantId = $2 AND revokedAt IS NULL`,
+		keyID, tenant.FromContext(ctx))
+	if err != nil {
+		lg.Error("failed to execute revoke query", "key_id", keyID, "err", err)
+		return fmt.Errorf("revoking api key: %w", err)
+	}
+
+	if r.RowsAffected() == 0 {
+		lg.Info("no api key revoked, not found", "key_id", keyID)
+		return ErrAPIKeyNotFound
+	}
+
+	lg.Info("api key revoked successfully", "key_id", keyID)
+	return nil
+}
+
+func (s *Storage) RotateAPIKey(ctx context.Context, keyID uuid.UUID, prefix string, hash string) error {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "RotateAPIKey")
+	lg.Info("rotating api key", "key_id", keyID)
+
+	r, err := s.pool.Exec(ctx,
+		`UPDATE api_key SET prefix = $1, hash = $2 WHERE keyId = $3 AND tenantId = $4 AND revokedAt IS NULL`,
+		prefix, hash, keyID, tenant.FromContext(ctx))
+	if err != nil {
+		lg.Error("failed to execute rotate query", "key_id", keyID, "err", err)
+		return fmt.Errorf("rotating api key: %w", err)
+	}
+
+	if r.RowsAffected() == 0 {
+		lg.Info("no api key rotated, not found", "key_id", keyID)
+		return ErrAPIKeyNotFound
+	}
+
+	lg.Info("api key rotated successfully", "key_id", keyID)
+	return nil
+}
+
+// FindAPIKey ищет действующий (не отозванный) ключ по его хешу среди всех арендаторов:
+// арендатор запроса определяется как раз по найденному ключу.
+func (s *Storage) FindAPIKey(ctx context.Context, hash string) (*entity.APIKey, error) {
+	key, err := scanAPIKey(s.pool.QueryRow(withAllTenants(ctx),
+		`SELECT `+apiKeyColumns+` FROM api_key WHERE hash = $1 AND revokedAt IS NULL`, hash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("query api key: %w", err)
+	}
+	return key, nil
+}
@@ -1,8 +1,136 @@
 package storage
 
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+)
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
 type Config struct {
 	DbName   string `yaml:"dbName"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
 	Address  string `yaml:"address"`
+	// AutoMigrate применяет миграции при запуске serve. Без него миграции
+	// выполняются отдельно командой migrate.
+	AutoMigrate bool `yaml:"auto_migrate"`
+
+	SSLMode         string        `yaml:"sslmode"`
+	SSLRootCert     string        `yaml:"sslrootcert"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout"`
+	ApplicationName string        `yaml:"application_name"`
+
+	Pool  PoolConfig  `yaml:"pool"`
+	Retry RetryConfig `yaml:"retry"`
+	// HealthCheckInterval — как часто проверять соединение с базой, чтобы
+	// сообщать о его потере и восстановлении; 0 отключает проверку.
+	HealthCheckInterval time.Duration `yaml:"health_check_interval"`
+	// Replicas — строки подключения к репликам только для чтения. На них уходят
+	// ReadSubs, ListSubs и TotalCost; недоступная реплика пропускается, пока
+	// проверка соединения не увидит её снова.
+	Replicas []string `yaml:"replicas"`
+}
+
+// PoolConfig — настройки пула pgxpool, нулевые значения оставляют умолчания pgx.
+type PoolConfig struct {
+	MaxConns int32 `yaml:"max_conns"`
+	// MinConns — сколько соединений пул держит открытыми даже без нагрузки.
+	MinConns        int32         `yaml:"min_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+// RetryConfig задаёт повторные попытки подключения при запуске: пауза
+// начинается с InitialBackoff и удваивается, но не превышает MaxBackoff.
+type RetryConfig struct {
+	Attempts       int           `yaml:"attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+// DSN собирает строку подключения вместе с параметрами соединения.
+func (c Config) DSN() string {
+	query := url.Values{}
+	if c.SSLMode != "" {
+		query.Set("sslmode", c.SSLMode)
+	}
+	if c.SSLRootCert != "" {
+		query.Set("sslrootcert", c.SSLRootCert)
+	}
+	if c.ConnectTimeout > 0 {
+		// libpq принимает целые секунды, меньше секунды округляем вверх
+		query.Set("connect_timeout", strconv.Itoa(max(int(c.ConnectTimeout/time.Second), 1)))
+	}
+	if c.ApplicationName != "" {
+		query.Set("application_name", c.ApplicationName)
+	}
+
+	return (&url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     c.Address,
+		Path:     c.DbName,
+		RawQuery: query.Encode(),
+	}).String()
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.Address == "" {
+		errs = append(errs, errors.New("address is required"))
+	}
+	if c.DbName == "" {
+		errs = append(errs, errors.New("dbName is required"))
+	}
+	if c.User == "" {
+		errs = append(errs, errors.New("user is required"))
+	}
+	if c.Password == "" {
+		errs = append(errs, errors.New("password is required"))
+	}
+	if c.SSLMode != "" && !slices.Contains(sslModes, c.SSLMode) {
+		errs = append(errs, fmt.Errorf("sslmode must be one of %v", sslModes))
+	}
+	if c.SSLRootCert != "" {
+		if _, err := os.Stat(c.SSLRootCert); err != nil {
+			errs = append(errs, fmt.Errorf("sslrootcert: %w", err))
+		}
+	}
+	if c.ConnectTimeout < 0 {
+		errs = append(errs, errors.New("connect_timeout must not be negative"))
+	}
+	if c.Pool.MaxConns < 0 || c.Pool.MinConns < 0 {
+		errs = append(errs, errors.New("pool.max_conns and pool.min_conns must not be negative"))
+	}
+	if c.Pool.MaxConns > 0 && c.Pool.MinConns > c.Pool.MaxConns {
+		errs = append(errs, errors.New("pool.min_conns must not exceed pool.max_conns"))
+	}
+	if c.Pool.ConnMaxLifetime < 0 || c.Pool.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("pool.conn_max_lifetime and pool.conn_max_idle_time must not be negative"))
+	}
+	if c.Retry.Attempts < 1 {
+		errs = append(errs, errors.New("retry.attempts must be at least 1"))
+	}
+	if c.Retry.InitialBackoff <= 0 || c.Retry.MaxBackoff < c.Retry.InitialBackoff {
+		errs = append(errs, errors.New("retry.initial_backoff must be positive and not exceed retry.max_backoff"))
+	}
+	if c.HealthCheckInterval < 0 {
+		errs = append(errs, errors.New("health_check_interval must not be negative"))
+	}
+	for i, dsn := range c.Replicas {
+		if _, err := pgx.ParseConfig(dsn); err != nil {
+			errs = append(errs, fmt.Errorf("replicas[%d]: %w", i, err))
+		}
+	}
+	if len(c.Replicas) > 0 && c.HealthCheckInterval == 0 {
+		errs = append(errs, errors.New("health_check_interval is required with replicas: it returns recovered replicas to rotation"))
+	}
+	return errors.Join(errs...)
 }
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	pgNotNullViolation    = "23502"
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+	pgStringTooLong       = "22001"
+	pgNumericOutOfRange   = "22003"
+	pgUndefinedTable      = "42P01"
+)
+
+var (
+	// ErrConstraint — данные нарушают ограничение схемы (CHECK, NOT NULL, длина поля).
+	ErrConstraint = errors.New("constraint violation")
+	// ErrConflict — запись с такими уникальными значениями уже существует.
+	ErrConflict = errors.New("conflicting record")
+)
+
+func pgErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// asConstraintError переводит нарушение ограничений Postgres в ErrConstraint
+// или ErrConflict с именем ограничения. Для остальных ошибок возвращает nil.
+func asConstraintError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+
+	name := pgErr.ConstraintName
+	if name == "" {
+		name = pgErr.ColumnName
+	}
+	if name == "" {
+		name = pgErr.Message
+	}
+
+	switch pgErr.Code {
+	case pgCheckViolation, pgNotNullViolation, pgStringTooLong, pgNumericOutOfRange:
+		return fmt.Errorf("%w: %s", ErrConstraint, name)
+	case pgUniqueViolation:
+		return fmt.Errorf("%w: %s", ErrConflict, name)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+)
+
+type MemberStorage interface {
+	AddMember(ctx context.Context, member *entity.Member) error
+	ListMembers(ctx context.Context, subsID uuid.UUID) ([]entity.Member, error)
+	RemoveMember(ctx context.Context, subsID uuid.UUID, userID uuid.UUID) error
+}
+
+var (
+	ErrMemberNotFound = errors.New("member not found")
+	ErrMemberIsPayer  = errors.New("subscription payer cannot be a member")
+)
+
+func (s *Storage) AddMember(ctx context.Context, member *entity.Member) error {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "AddMember")
+	lg.Info("adding subscription member",
+		"subscription_id", member.SubsID,
+		"user_id", member.UserId,
+		"split", member.Split,
+		"value", member.Value,
+	)
+
+	var payer uuid.UUID
+	err := s.pool.QueryRow(ctx,
+		`SELECT userID FROM subscription WHERE subscriptionId = $1 AND tenantId = $2`,
+		member.SubsID, tenant.FromContext(ctx)).Scan(&payer)
+	if errors.Is(err, pgx.ErrNoRows) {
+		lg.Info("subscription not found", "subscription_id", member.SubsID)
+		return ErrNotFound
+	} else if err != nil {
+		lg.Error("failed to query subscription payer", "err", err)
+		return fmt.Errorf("query subscription payer: %w", err)
+	}
+	if payer == member.UserId {
+		lg.Info("member is the subscription payer", "user_id", member.UserId)
+		return ErrMemberIsPayer
+	}
+
+	var exists bool
+	err = s.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM users WHERE userId = $1 AND tenantId = $2)`,
+		member.UserId, tenant.FromContext(ctx)).Scan(&exists)
+	if err != nil {
+		lg.Error("failed to check member user", "err", err)
+		return fmt.Errorf("check member user: %w", err)
+	}
+	if !exists {
+		lg.Info("member references unknown user", "user_id", member.UserId)
+		return ErrUserNotFound
+	}
+
+	_, err = s.pool.Exec(ctx,
+		`INSERT INTO subscription_member(subscriptionId, userId, split, value)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (subscriptionId, userId)
+		 DO UPDATE SET split = EXCLUDED.split, value = EXCLUDED.value`,
+		member.SubsID, member.UserId, member.Split, member.Value,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			if pgErr.ConstraintName == "fk_member_user" {
+				lg.Info("member references unknown user", "user_id", member.UserId)
+				return ErrUserNotFound
+			}
+			lg.Info("member references unknown subscription", "subscription_id", member.SubsID)
+			return ErrNotFound
+		}
+		if cerr := asConstraintError(err); cerr != nil {
+			lg.Info("constraint violated", "err", cerr)
+			return cerr
+		}
+		lg.Error("failed to add subscription member", "err", err)
+		return fmt.Errorf("add member: %w", err)
+	}
+
+	lg.Info("subscription member added successfully", "subscription_id", member.SubsID, "user_id", member.UserId)
+	return nil
+}
+
+func (s *Storage) ListMembers(ctx context.Context, subsID uuid.UUID) ([]entity.Member, error) {
+	members := make([]entity.Member, 0)
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT m.subscriptionId, m.userId, m.split, m.value
+        FROM subscription_member m
+        JOIN subscription s ON s.subscriptionId = m.subscriptionId
+        WHERE m.subscriptionId = $1 AND s.tenantId = $2
+        ORDER BY m.userId
+    `, subsID, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("listing members: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m entity.Member
+		if err = rows.Scan(&m.SubsID, &m.UserId, &m.Split, &m.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan member row: %w", err)
+		}
+		members = append(members, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return members, nil
+}
+
+// MemberSubs возвращает подписки, в которых пользователь участвует, не оплачивая их.
+func (s *Storage) MemberSubs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := s.pool.Query(ctx, `
+        SELECT m.subscriptionId
+        FROM subscription_member m
+        JOIN subscription s ON s.subscriptionId = m.subscriptionId
+        WHERE m.userId = $1 AND s.tenantId = $2
+    `, userID, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("listing member subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan member subscription row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (s *Storage) RemoveMember(ctx context.Context, subsID uuid.UUID, userID uuid.UUID) error {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "RemoveMember")
+	lg.Info("removing subscription member", "subscription_id", subsID, "user_id", userID)
+
+	r, err := s.pool.Exec(ctx,
+		`DELETE FROM subscription_member m
+		 USING subscription s
+		 WHERE s.subscriptionId = m.subscriptionId
+		   AND m.subscriptionId = $1 AND m.userId = $2 AND s.tenantId = $3`,
+		subsID, userID, tenant.FromContext(ctx))
+	if err != nil {
+		lg.Error("failed to execute delete query", "err", err)
+		return fmt.Errorf("removing a member: %w", err)
+	}
+
+	if r.RowsAffected() == 0 {
+		lg.Info("no member removed, not found", "subscription_id", subsID, "user_id", userID)
+		return ErrMemberNotFound
+	}
+
+	lg.Info("subscription member removed successfully", "subscription_id", subsID, "user_id", userID)
+	return nil
+}
+
+// fairShare считает по результату stmtSharedSubs долю пользователя в подписках
+// сервиса, которые он оплачивает или в которых участвует. Доли учитываются по
+// тем же правилам, что и цены в TotalCost, включая режим пропорционального расчёта.
+func fairShare(rows pgx.Rows, t entity.TotalCost) (int, error) {
+	defer rows.Close()
+
+	type shared struct {
+		price     int
+		payer     uuid.UUID
+		startDate time.Time
+		endDate   *time.Time
+		members   []entity.Member
+	}
+	subs := make(map[uuid.UUID]*shared)
+
+	for rows.Next() {
+		var (
+			subsID   uuid.UUID
+			sub      shared
+			memberID *uuid.UUID
+			split    *string
+			value    *int
+		)
+		err := rows.Scan(&subsID, &sub.price, &sub.payer, &sub.startDate, &sub.endDate, &memberID, &split, &value)
+		if err != nil {
+			return 0, fmt.Errorf("failed to scan shared subscription row: %w", err)
+		}
+
+		existing, ok := subs[subsID]
+		if !ok {
+			existing = &sub
+			subs[subsID] = existing
+		}
+		if memberID != nil {
+			existing.members = append(existing.members, entity.Member{
+				SubsID: subsID,
+				UserId: *memberID,
+				Split:  entity.SplitType(*split),
+				Value:  *value,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	items := make([]entity.CostItem, 0, len(subs))
+	for _, sub := range subs {
+		items = append(items, entity.CostItem{
+			Price:     entity.FairShares(sub.price, sub.payer, sub.members)[t.UserId],
+			StartDate: sub.startDate,
+			EndDate:   sub.endDate,
+		})
+	}
+
+	return entity.Cost(items, t.Date1, t.Date2, t.Proration), nil
+}
@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	migrate "github.com/rubenv/sql-migrate"
+	"strings"
+	"time"
+)
+
+// Миграции встроены в бинарник, поэтому он не зависит от каталога на диске.
+//
+//go:embed migrate/*.sql
+var migrationFiles embed.FS
+
+// migrationTable — таблица sql-migrate с применёнными миграциями (имя по умолчанию).
+const migrationTable = "gorp_migrations"
+
+var ErrUnknownMigration = errors.New("unknown migration")
+
+type MigrationStatus struct {
+	ID        string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+func (s *Storage) migrations() migrate.MigrationSource {
+	return &migrate.EmbedFileSystemMigrationSource{
+		FileSystem: migrationFiles,
+		Root:       "migrate",
+	}
+}
+
+func (s *Storage) Migrate(direction migrate.MigrationDirection) error {
+	s.lg.Info("starting database migration", "direction", direction)
+
+	s.migrated.Store(false)
+	n, err := migrate.Exec(s.db, "postgres", s.migrations(), direction)
+	if err != nil {
+		s.lg.Error("database migration failed", "err", err)
+		return fmt.Errorf("error for migrate: %v", err)
+	}
+	s.migrated.Store(direction == migrate.Up)
+
+	s.lg.Info("database migration completed successfully", "migrations_applied", n)
+	return nil
+}
+
+// MigrateTo применяет миграции до target включительно (Up) или откатывает
+// миграции, применённые после target (Down). Пустой target при Up означает
+// все миграции, при Down — одну последнюю. target — ID файла с расширением
+// или без него либо префикс версии, например "v05".
+func (s *Storage) MigrateTo(direction migrate.MigrationDirection, target string) (int, error) {
+	lg := s.lg.With("direction", direction, "target", target)
+	lg.Info("starting database migration")
+
+	max, err := s.migrationCount(direction, target)
+	if err != nil {
+		lg.Error("failed to plan migration", "err", err)
+		return 0, err
+	}
+	if max == 0 {
+		lg.Info("no migrations to apply")
+		return 0, nil
+	}
+
+	s.migrated.Store(false)
+	n, err := migrate.ExecMax(s.db, "postgres", s.migrations(), direction, max)
+	if err != nil {
+		lg.Error("database migration failed", "err", err)
+		return n, fmt.Errorf("migrate: %w", err)
+	}
+
+	lg.Info("database migration completed successfully", "migrations_applied", n)
+	return n, nil
+}
+
+// RedoMigration откатывает и заново применяет последнюю миграцию.
+func (s *Storage) RedoMigration() (string, error) {
+	status, err := s.MigrationStatus()
+	if err != nil {
+		return "", err
+	}
+	last := ""
+	for _, m := range status {
+		if m.Applied {
+			last = m.ID
+		}
+	}
+	if last == "" {
+		return "", errors.New("redo: no applied migrations")
+	}
+
+	if _, err = s.MigrateTo(migrate.Down, ""); err != nil {
+		return last, fmt.Errorf("redo %s: %w", last, err)
+	}
+	s.migrated.Store(false)
+	if _, err = migrate.ExecMax(s.db, "postgres", s.migrations(), migrate.Up, 1); err != nil {
+		return last, fmt.Errorf("redo %s: %w", last, err)
+	}
+	s.lg.Info("migration redone", "migration", last)
+	return last, nil
+}
+
+// MigrationStatus возвращает все известные миграции по порядку применения
+// с отметкой, применены ли они к базе.
+func (s *Storage) MigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := s.migrations().FindMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("find migrations: %w", err)
+	}
+	records, err := migrate.GetMigrationRecords(s.db, "postgres")
+	if err != nil {
+		return nil, fmt.Errorf("get migration records: %w", err)
+	}
+
+	applied := make(map[string]time.Time, len(records))
+	for _, r := range records {
+		applied[r.Id] = r.AppliedAt
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		at, ok := applied[m.Id]
+		status = append(status, MigrationStatus{ID: m.Id, Applied: ok, AppliedAt: at})
+	}
+	return status, nil
+}
+
+// PendingMigrations возвращает число миграций, которые ещё не применены к базе.
+// Встроенный набор миграций не меняется, поэтому, когда все они применены,
+// ответ запоминается и проверка готовности больше не обращается к базе;
+// его сбрасывает любая миграция этого процесса. Запрос к базе соблюдает ctx.
+func (s *Storage) PendingMigrations(ctx context.Context) (int, error) {
+	if s.migrated.Load() {
+		return 0, nil
+	}
+
+	migrations, err := s.migrations().FindMigrations()
+	if err != nil {
+		return 0, fmt.Errorf("find migrations: %w", err)
+	}
+
+	applied := make(map[string]bool, len(migrations))
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM `+migrationTable)
+	switch {
+	case pgErrorCode(err) == pgUndefinedTable:
+		// таблицы ещё нет: не применена ни одна миграция
+	case err != nil:
+		return 0, fmt.Errorf("get migration records: %w", err)
+	default:
+		defer rows.Close()
+		for rows.Next() {
+			var id string
+			if err = rows.Scan(&id); err != nil {
+				return 0, fmt.Errorf("scan migration record: %w", err)
+			}
+			applied[id] = true
+		}
+		if err = rows.Err(); err != nil {
+			return 0, fmt.Errorf("get migration records: %w", err)
+		}
+	}
+
+	pending := 0
+	for _, m := range migrations {
+		if !applied[m.Id] {
+			pending++
+		}
+	}
+	if pending == 0 {
+		s.migrated.Store(true)
+	}
+	return pending, nil
+}
+
+// migrationCount считает, сколько миграций нужно применить или откатить,
+// чтобы дойти до target. Версии миграций не числовые (v03-…), поэтому
+// ExecVersion из sql-migrate здесь не подходит.
+func (s *Storage) migrationCount(direction migrate.MigrationDirection, target string) (int, error) {
+	status, err := s.MigrationStatus()
+	if err != nil {
+		return 0, err
+	}
+
+	idx := -1
+	if target != "" {
+		for i, m := range status {
+			if matchMigration(m.ID, target) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return 0, fmt.Errorf("%w %q", ErrUnknownMigration, target)
+		}
+	}
+
+	count := 0
+	switch direction {
+	case migrate.Up:
+		for i, m := range status {
+			if !m.Applied && (idx < 0 || i <= idx) {
+				count++
+			}
+		}
+	case migrate.Down:
+		if idx < 0 {
+			for _, m := range status {
+				if m.Applied {
+					return 1, nil
+				}
+			}
+			return 0, nil
+		}
+		for i, m := range status {
+			if m.Applied && i > idx {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func matchMigration(id, target string) bool {
+	return id == target ||
+		strings.TrimSuffix(id, ".sql") == target ||
+		strings.HasPrefix(id, target+"-")
+}
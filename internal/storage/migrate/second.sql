@@ -1,44 +1,12 @@
--- +migrate Up
-
-INSERT INTO subscription (serviceName, price, userID, startDate, endDate) VALUES
--- Пользователь 1: несколько Netflix подряд (частично пересекаются)
-('Netflix', 1200, '11111111-1111-1111-1111-111111111111', '2025-01-01', '2025-03-31'),
-('Netflix', 1500, '11111111-1111-1111-1111-111111111111', '2025-02-01', '2025-05-31'),
-
--- Пользователь 1: Spotify без конца (NULL в endDate)
-('Spotify', 800,  '11111111-1111-1111-1111-111111111111', '2025-03-01', NULL),
-
--- Пользователь 2: YouTube Premium (месяц за месяцем)
-('YouTube Premium', 999, '22222222-2222-2222-2222-222222222222', '2025-06-01', '2025-06-30'),
-('YouTube Premium', 999, '22222222-2222-2222-2222-222222222222', '2025-07-01', '2025-07-31'),
-
--- Пользователь 3: две разные подписки, пересекаются
-('Disney+', 1100, '33333333-3333-3333-3333-333333333333', '2025-04-01', '2025-08-31'),
-('Netflix', 1200, '33333333-3333-3333-3333-333333333333', '2025-06-01', '2025-12-31'),
+-- Раньше эта миграция вставляла демонстрационные подписки. Демо-данные перенесены
+-- в команду "binary seed apply demo", а файл оставлен пустым, потому что миграция
+-- уже записана в gorp_migrations существующих баз.
 
--- Пользователь 4: Spotify только в августе
-('Spotify', 800,  '44444444-4444-4444-4444-444444444444', '2025-08-01', '2025-08-31'),
-
--- Пользователь 5: Apple TV+ и Coursera (оба пересекаются в июне–июле)
-('Apple TV+', 1000, '55555555-5555-5555-5555-555555555555', '2025-03-01', '2025-09-30'),
-('Coursera Plus', 3300, '55555555-5555-5555-5555-555555555555', '2025-06-01', '2025-07-31'),
-
--- Пользователь 6: три разных подписки в июне–августе
-('Netflix', 1200, '66666666-6666-6666-6666-666666666666', '2025-08-01', NULL),
-('Spotify', 800,  '66666666-6666-6666-6666-666666666666', '2025-07-01', '2025-07-31'),
-('YouTube Premium', 999, '66666666-6666-6666-6666-666666666666', '2025-06-01', '2025-08-31');
+-- +migrate Up
 
+SELECT 1;
 
 
 -- +migrate Down
 
-DELETE FROM subscription
-WHERE userID IN (
-                 '11111111-1111-1111-1111-111111111111',
-                 '22222222-2222-2222-2222-222222222222',
-                 '33333333-3333-3333-3333-333333333333',
-                 '44444444-4444-4444-4444-444444444444',
-                 '55555555-5555-5555-5555-555555555555',
-                 '66666666-6666-6666-6666-666666666666'
-    );
-
+SELECT 1;
@@ -0,0 +1,26 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS users (
+    userId UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(50) NOT NULL CHECK (LENGTH(name) >= 2),
+    email VARCHAR(254) NOT NULL UNIQUE,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    createdAt TIMESTAMPTZ NOT NULL DEFAULT now()
+    );
+
+-- существующие подписки ссылаются на произвольные UUID, заводим для них пользователей
+INSERT INTO users (userId, name, email)
+SELECT DISTINCT userID, 'user ' || LEFT(userID::text, 8), userID::text || '@example.com'
+FROM subscription
+ON CONFLICT (userId) DO NOTHING;
+
+ALTER TABLE subscription
+    ADD CONSTRAINT fk_subscription_user
+    FOREIGN KEY (userID) REFERENCES users (userId) ON DELETE CASCADE;
+
+
+-- +migrate Down
+
+ALTER TABLE subscription DROP CONSTRAINT IF EXISTS fk_subscription_user;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,23 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS subscription_member (
+    subscriptionId UUID NOT NULL,
+    userId UUID NOT NULL,
+    split VARCHAR(10) NOT NULL DEFAULT 'equal' CHECK (split IN ('equal', 'percentage', 'fixed')),
+    value INT NOT NULL DEFAULT 0 CHECK (value >= 0),
+    PRIMARY KEY (subscriptionId, userId),
+    CONSTRAINT fk_member_subscription
+        FOREIGN KEY (subscriptionId) REFERENCES subscription (subscriptionId) ON DELETE CASCADE,
+    CONSTRAINT fk_member_user
+        FOREIGN KEY (userId) REFERENCES users (userId) ON DELETE CASCADE,
+    CHECK (split <> 'percentage' OR value <= 100)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_subscription_member_user
+    ON subscription_member (userId);
+
+
+-- +migrate Down
+
+DROP INDEX IF EXISTS idx_subscription_member_user;
+DROP TABLE IF EXISTS subscription_member;
@@ -0,0 +1,16 @@
+-- +migrate Up
+
+CREATE TABLE IF NOT EXISTS api_key (
+    keyId UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(50) NOT NULL CHECK (LENGTH(name) >= 2),
+    prefix VARCHAR(16) NOT NULL,
+    hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    createdAt TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revokedAt TIMESTAMPTZ
+    );
+
+
+-- +migrate Down
+
+DROP TABLE IF EXISTS api_key;
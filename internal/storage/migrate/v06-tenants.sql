@@ -0,0 +1,62 @@
+-- +migrate Up
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenantId VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE subscription ADD COLUMN IF NOT EXISTS tenantId VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE api_key ADD COLUMN IF NOT EXISTS tenantId VARCHAR(64) NOT NULL DEFAULT 'default';
+
+-- email уникален в пределах арендатора
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_tenant_email_key UNIQUE (tenantId, email);
+ALTER TABLE users ADD CONSTRAINT users_tenant_user_key UNIQUE (tenantId, userId);
+
+-- подписка может принадлежать только пользователю своего арендатора
+ALTER TABLE subscription DROP CONSTRAINT IF EXISTS fk_subscription_user;
+ALTER TABLE subscription
+    ADD CONSTRAINT fk_subscription_user
+    FOREIGN KEY (tenantId, userID) REFERENCES users (tenantId, userId) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_tenant_start
+    ON subscription (tenantId, startDate);
+
+-- Row-level security как дополнительная защита: сессия, выставившая
+-- app.tenant_id, видит только строки своего арендатора. Приложение
+-- дополнительно фильтрует по tenantId в каждом запросе.
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE users FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON users
+    USING (tenantId = COALESCE(NULLIF(current_setting('app.tenant_id', true), ''), tenantId));
+
+ALTER TABLE subscription ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON subscription
+    USING (tenantId = COALESCE(NULLIF(current_setting('app.tenant_id', true), ''), tenantId));
+
+ALTER TABLE api_key ENABLE ROW LEVEL SECURITY;
+ALTER TABLE api_key FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON api_key
+    USING (tenantId = COALESCE(NULLIF(current_setting('app.tenant_id', true), ''), tenantId));
+
+
+-- +migrate Down
+
+DROP POLICY IF EXISTS tenant_isolation ON api_key;
+ALTER TABLE api_key DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON subscription;
+ALTER TABLE subscription DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON users;
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS idx_subscription_tenant_start;
+
+ALTER TABLE subscription DROP CONSTRAINT IF EXISTS fk_subscription_user;
+ALTER TABLE subscription
+    ADD CONSTRAINT fk_subscription_user
+    FOREIGN KEY (userID) REFERENCES users (userId) ON DELETE CASCADE;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_tenant_user_key;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_tenant_email_key;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+ALTER TABLE api_key DROP COLUMN IF EXISTS tenantId;
+ALTER TABLE subscription DROP COLUMN IF EXISTS tenantId;
+ALTER TABLE users DROP COLUMN IF EXISTS tenantId;
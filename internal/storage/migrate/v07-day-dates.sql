@@ -0,0 +1,24 @@
+-- +migrate Up
+
+-- Даты подписок хранятся с точностью до дня, дата окончания включается в период.
+-- Раньше окончание задавалось месяцем и хранилось первым днём этого месяца,
+-- поэтому такие даты переносятся на последний день месяца.
+UPDATE subscription
+SET endDate = (date_trunc('month', endDate) + interval '1 month - 1 day')::date
+WHERE endDate IS NOT NULL;
+
+ALTER TABLE subscription DROP CONSTRAINT IF EXISTS subscription_check;
+ALTER TABLE subscription
+    ADD CONSTRAINT subscription_dates_check CHECK (endDate IS NULL OR endDate >= startDate);
+
+-- +migrate Down
+
+ALTER TABLE subscription DROP CONSTRAINT IF EXISTS subscription_dates_check;
+
+UPDATE subscription
+SET endDate = date_trunc('month', endDate)::date
+WHERE endDate IS NOT NULL;
+
+-- подписки длиной в один месяц старому ограничению не соответствуют
+ALTER TABLE subscription
+    ADD CONSTRAINT subscription_check CHECK (endDate IS NULL OR endDate > startDate) NOT VALID;
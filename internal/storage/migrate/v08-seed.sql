@@ -0,0 +1,33 @@
+-- +migrate Up
+
+-- пользователи, созданные командой seed; удаление пользователя каскадно
+-- удаляет его подписки, участие в них и запись о наборе
+CREATE TABLE IF NOT EXISTS seed_user (
+    dataset VARCHAR(32) NOT NULL,
+    tenantId VARCHAR(64) NOT NULL,
+    userId UUID NOT NULL,
+    PRIMARY KEY (dataset, tenantId, userId),
+    CONSTRAINT fk_seed_user
+        FOREIGN KEY (tenantId, userId) REFERENCES users (tenantId, userId) ON DELETE CASCADE
+    );
+
+-- демо-данные прежней миграции second.sql считаются набором demo,
+-- чтобы их можно было удалить командой "binary seed remove demo"
+INSERT INTO seed_user (dataset, tenantId, userId)
+SELECT 'demo', tenantId, userId
+FROM users
+WHERE userId IN (
+                 '11111111-1111-1111-1111-111111111111',
+                 '22222222-2222-2222-2222-222222222222',
+                 '33333333-3333-3333-3333-333333333333',
+                 '44444444-4444-4444-4444-444444444444',
+                 '55555555-5555-5555-5555-555555555555',
+                 '66666666-6666-6666-6666-666666666666'
+    )
+  AND email = userId::text || '@example.com'
+ON CONFLICT DO NOTHING;
+
+
+-- +migrate Down
+
+DROP TABLE IF EXISTS seed_user;
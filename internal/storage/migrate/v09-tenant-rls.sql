@@ -0,0 +1,54 @@
+-- +migrate Up
+
+-- Политики v06 пропускали все строки, пока сессия не выставит app.tenant_id.
+-- Теперь сессия без арендатора не видит ничего; системные запросы (поиск
+-- API-ключа, метрики, миграции) явно включают app.all_tenants.
+DROP POLICY IF EXISTS tenant_isolation ON users;
+CREATE POLICY tenant_isolation ON users
+    USING (tenantId = current_setting('app.tenant_id', true)
+        OR current_setting('app.all_tenants', true) = 'on');
+
+DROP POLICY IF EXISTS tenant_isolation ON subscription;
+CREATE POLICY tenant_isolation ON subscription
+    USING (tenantId = current_setting('app.tenant_id', true)
+        OR current_setting('app.all_tenants', true) = 'on');
+
+DROP POLICY IF EXISTS tenant_isolation ON api_key;
+CREATE POLICY tenant_isolation ON api_key
+    USING (tenantId = current_setting('app.tenant_id', true)
+        OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE seed_user ENABLE ROW LEVEL SECURITY;
+ALTER TABLE seed_user FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON seed_user
+    USING (tenantId = current_setting('app.tenant_id', true)
+        OR current_setting('app.all_tenants', true) = 'on');
+
+-- у участника нет своего арендатора: строка видна и может быть добавлена,
+-- только если видна её подписка, к которой применяется своя политика
+ALTER TABLE subscription_member ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_member FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON subscription_member
+    USING (EXISTS (
+        SELECT 1 FROM subscription s WHERE s.subscriptionId = subscription_member.subscriptionId
+    ));
+
+
+-- +migrate Down
+
+DROP POLICY IF EXISTS tenant_isolation ON subscription_member;
+ALTER TABLE subscription_member DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON seed_user;
+ALTER TABLE seed_user DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON api_key;
+CREATE POLICY tenant_isolation ON api_key
+    USING (tenantId = COALESCE(NULLIF(current_setting('app.tenant_id', true), ''), tenantId));
+
+DROP POLICY IF EXISTS tenant_isolation ON subscription;
+CREATE POLICY tenant_isolation ON subscription
+    USING (tenantId = COALESCE(NULLIF(current_setting('app.tenant_id', true), ''), tenantId));
+
+DROP POLICY IF EXISTS tenant_isolation ON users;
+CREATE POLICY tenant_isolation ON users
+    USING (tenantId = COALESCE(NULLIF(current_setting('app.tenant_id', true), ''), tenantId));
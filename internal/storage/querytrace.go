@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+var tracer = otel.Tracer("github.com/AndreySirin/-Effective-Mobile-/internal/storage")
+
+// preparedSQL — текст подготовленных запросов по имени: pgx передаёт
+// трассировщику имя, а в спан нужен сам запрос.
+var preparedSQL = func() map[string]string {
+	stmts := []statement{
+		stmtCreateSubs, stmtReadSubs, stmtUpdateSubs, stmtDeleteSubs,
+		stmtListSubs, stmtPaidSubs, stmtSharedSubs,
+	}
+	m := make(map[string]string, len(stmts))
+	for _, stmt := range stmts {
+		m[stmt.name] = strings.TrimSpace(stmt.sql)
+	}
+	return m
+}()
+
+// queryTracer открывает клиентский спан на каждый запрос, пакет и COPY всех
+// пулов хранилища, поэтому в трейсе видны запросы любых методов, а не только
+// SubscriptionStorage.
+type queryTracer struct{}
+
+func startQuerySpan(ctx context.Context, name string, attrs ...attribute.KeyValue) context.Context {
+	ctx, _ = tracer.Start(ctx, "sql "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx
+}
+
+func endQuerySpan(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil && err != pgx.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// operation возвращает имя подготовленного запроса или первое слово SQL.
+func operation(sql string) (name, text string) {
+	if text, ok := preparedSQL[sql]; ok {
+		return sql, text
+	}
+	text = strings.TrimSpace(sql)
+	name, _, _ = strings.Cut(text, " ")
+	return strings.ToUpper(name), text
+}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	name, text := operation(data.SQL)
+	return startQuerySpan(ctx, name, semconv.DBOperationName(name), semconv.DBQueryText(text))
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	endQuerySpan(ctx, data.Err)
+}
+
+func (queryTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	return startQuerySpan(ctx, "batch", attribute.Int("db.batch.size", data.Batch.Len()))
+}
+
+// TraceBatchQuery отмечает событием каждый запрос пакета: отдельного
+// времени выполнения у них нет, результаты приходят одним ответом.
+func (queryTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	name, _ := operation(data.SQL)
+	attrs := []attribute.KeyValue{semconv.DBOperationName(name)}
+	if data.Err != nil {
+		attrs = append(attrs, attribute.String("error", data.Err.Error()))
+	}
+	trace.SpanFromContext(ctx).AddEvent("query", trace.WithAttributes(attrs...))
+}
+
+func (queryTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	endQuerySpan(ctx, data.Err)
+}
+
+func (queryTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	return startQuerySpan(ctx, "COPY "+data.TableName.Sanitize(),
+		semconv.DBOperationName("COPY"), semconv.DBCollectionName(data.TableName.Sanitize()))
+}
+
+func (queryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	endQuerySpan(ctx, data.Err)
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// replicaPingTimeout ограничивает проверку реплики при запуске, чтобы
+// недоступная реплика не задерживала старт сервиса.
+const replicaPingTimeout = 5 * time.Second
+
+// replica — пул соединений с репликой только для чтения.
+type replica struct {
+	// host — адрес реплики без учётных данных, для логов.
+	host    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+func openReplica(lg *slog.Logger, dsn string, cfg PoolConfig) (*replica, error) {
+	pool, err := openPool(dsn, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("init replica: %w", err)
+	}
+	conn := pool.Config().ConnConfig
+	r := &replica{host: net.JoinHostPort(conn.Host, strconv.Itoa(int(conn.Port))), pool: pool}
+
+	// недоступная при запуске реплика не мешает работе: чтения идут в основную
+	// базу, пока Watch не увидит реплику снова
+	ctx, cancel := context.WithTimeout(context.Background(), replicaPingTimeout)
+	defer cancel()
+	if err = pool.Ping(ctx); err != nil {
+		lg.Warn("replica is unavailable, reads go to primary until it recovers", "replica", r.host, "err", err)
+		return r, nil
+	}
+	r.healthy.Store(true)
+	lg.Info("replica connection opened successfully", "replica", r.host)
+	return r, nil
+}
+
+type primaryKey struct{}
+
+// WithPrimary помечает контекст: чтения в нём выполняются в основной базе.
+// Так клиент сразу после записи видит свои изменения, не дожидаясь реплик.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// PrimaryRequired сообщает, что чтения в ctx должны видеть последние записи.
+func PrimaryRequired(ctx context.Context) bool {
+	required, _ := ctx.Value(primaryKey{}).(bool)
+	return required
+}
+
+// pickReplica возвращает следующую по кругу доступную реплику или nil,
+// если чтение должно идти в основную базу.
+func (s *Storage) pickReplica(ctx context.Context) *replica {
+	if len(s.replicas) == 0 || PrimaryRequired(ctx) {
+		return nil
+	}
+	start := s.next.Add(1)
+	for i := range s.replicas {
+		r := s.replicas[(start+uint64(i))%uint64(len(s.replicas))]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// withReadPrepared выполняет запрос только для чтения на реплике, а если
+// доступных реплик нет или соединение с ней оборвалось — в основной базе.
+// fn может выполниться дважды и должна перезаписывать свои результаты.
+func (s *Storage) withReadPrepared(ctx context.Context, fn func(conn *pgx.Conn) error, stmts ...statement) error {
+	r := s.pickReplica(ctx)
+	if r == nil {
+		return withPrepared(ctx, s.pool, fn, stmts...)
+	}
+
+	err := withPrepared(ctx, r.pool, fn, stmts...)
+	if !connectionLost(ctx, err) {
+		return err
+	}
+	if r.healthy.CompareAndSwap(true, false) {
+		s.lg.Warn("replica connection lost, reads go to primary", "replica", r.host, "err", err)
+	}
+	return withPrepared(ctx, s.pool, fn, stmts...)
+}
+
+// connectionLost отличает сбой соединения от ошибки самого запроса:
+// только в первом случае имеет смысл повторить чтение в другой базе.
+func connectionLost(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var connErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connErr) || errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// checkReplicas проверяет соединение с каждой репликой и возвращает
+// восстановившиеся реплики в ротацию.
+func (s *Storage) checkReplicas(ctx context.Context, lg *slog.Logger, timeout time.Duration) {
+	for _, r := range s.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := r.pool.Ping(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case err != nil && r.healthy.CompareAndSwap(true, false):
+			lg.Error("replica connection lost", "replica", r.host, "err", err)
+		case err == nil && r.healthy.CompareAndSwap(false, true):
+			lg.Info("replica connection restored", "replica", r.host)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"sync"
+)
+
+// Политики row-level security (миграция v09) пропускают строки арендатора
+// из переменной сессии app.tenant_id, а при app.all_tenants = 'on' — все строки.
+// Сессия без этих переменных не видит ничего.
+
+type allTenantsKey struct{}
+
+// withAllTenants снимает ограничение RLS для системных запросов, которые по
+// смыслу обходят всех арендаторов: поиск API-ключа и метрики.
+func withAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey{}, true)
+}
+
+// sessionTenant возвращает значения app.tenant_id и app.all_tenants для ctx.
+// Контекст без арендатора не получает доступа ни к одной строке.
+func sessionTenant(ctx context.Context) (tenantID, allTenants string) {
+	if all, _ := ctx.Value(allTenantsKey{}).(bool); all {
+		return "", "on"
+	}
+	id, _ := tenant.Lookup(ctx)
+	return id, "off"
+}
+
+// tenantSessions выставляет соединению переменные RLS арендатора из контекста
+// при каждом захвате из пула. Последние выставленные значения запоминаются,
+// чтобы повторный захват тем же арендатором не обращался к базе.
+type tenantSessions struct {
+	current sync.Map // *pgx.Conn → tenantID + allTenants
+}
+
+func (t *tenantSessions) beforeAcquire(ctx context.Context, conn *pgx.Conn) bool {
+	tenantID, allTenants := sessionTenant(ctx)
+	key := allTenants + ":" + tenantID
+	if v, ok := t.current.Load(conn); ok && v == key {
+		return true
+	}
+
+	_, err := conn.Exec(ctx,
+		`SELECT set_config('app.tenant_id', $1, false), set_config('app.all_tenants', $2, false)`,
+		tenantID, allTenants)
+	if err != nil {
+		// соединение с неизвестным состоянием сессии закрывается, пул возьмёт другое
+		t.current.Delete(conn)
+		return false
+	}
+	t.current.Store(conn, key)
+	return true
+}
+
+func (t *tenantSessions) beforeClose(conn *pgx.Conn) {
+	t.current.Delete(conn)
+}
+
+// allTenantsSession включает app.all_tenants на соединениях sql-migrate:
+// миграции данных обходят всех арендаторов.
+func allTenantsSession(ctx context.Context, conn *pgx.Conn) error {
+	_, err := conn.Exec(ctx, `SELECT set_config('app.all_tenants', 'on', false)`)
+	return err
+}
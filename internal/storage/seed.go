@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrSeedApplied = errors.New("dataset already applied")
+
+// ApplySeed в одной транзакции создаёт пользователей и подписки тестового набора
+// в арендаторе из контекста и запоминает пользователей в seed_user. Строки
+// загружаются через COPY, поэтому большие наборы вставляются быстро.
+func (s *Storage) ApplySeed(ctx context.Context, dataset string, users []entity.User, subs []entity.Subscription) error {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "ApplySeed")
+	tenantID := tenant.FromContext(ctx)
+	lg.Info("applying dataset", "dataset", dataset, "tenant", tenantID, "users", len(users), "subscriptions", len(subs))
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var applied bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM seed_user WHERE dataset = $1 AND tenantId = $2)`,
+		dataset, tenantID).Scan(&applied)
+	if err != nil {
+		return fmt.Errorf("check dataset: %w", err)
+	}
+	if applied {
+		lg.Info("dataset already applied", "dataset", dataset)
+		return ErrSeedApplied
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"users"},
+		[]string{"userid", "name", "email", "timezone", "currency", "tenantid"},
+		pgx.CopyFromSlice(len(users), func(i int) ([]any, error) {
+			u := users[i]
+			return []any{u.UserId, u.Name, u.Email, u.Timezone, u.Currency, tenantID}, nil
+		}))
+	if err != nil {
+		if pgErrorCode(err) == pgUniqueViolation {
+			return fmt.Errorf("copy users: %w", ErrConflict)
+		}
+		return fmt.Errorf("copy users: %w", err)
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"seed_user"},
+		[]string{"dataset", "tenantid", "userid"},
+		pgx.CopyFromSlice(len(users), func(i int) ([]any, error) {
+			return []any{dataset, tenantID, users[i].UserId}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("copy seed users: %w", err)
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"subscription"},
+		[]string{"servicename", "price", "userid", "startdate", "enddate", "tenantid"},
+		pgx.CopyFromSlice(len(subs), func(i int) ([]any, error) {
+			sub := subs[i]
+			return []any{sub.ServiceName, sub.Price, sub.UserId, sub.StartDate, sub.EndDate, tenantID}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("copy subscriptions: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	lg.Info("dataset applied successfully", "dataset", dataset, "tenant", tenantID)
+	return nil
+}
+
+// RemoveSeed удаляет пользователей набора в арендаторе из контекста вместе
+// с их подписками и возвращает число удалённых пользователей.
+func (s *Storage) RemoveSeed(ctx context.Context, dataset string) (int64, error) {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "RemoveSeed")
+	tenantID := tenant.FromContext(ctx)
+	lg.Info("removing dataset", "dataset", dataset, "tenant", tenantID)
+
+	r, err := s.pool.Exec(ctx, `
+        DELETE FROM users u
+        USING seed_user s
+        WHERE s.dataset = $1
+          AND s.tenantId = $2
+          AND u.tenantId = s.tenantId
+          AND u.userId = s.userId
+    `, dataset, tenantID)
+	if err != nil {
+		lg.Error("failed to remove dataset", "err", err)
+		return 0, fmt.Errorf("remove dataset: %w", err)
+	}
+
+	n := r.RowsAffected()
+	lg.Info("dataset removed successfully", "dataset", dataset, "users", n)
+	return n, nil
+}
+
+// SeededDatasets возвращает число пользователей каждого применённого набора
+// в арендаторе из контекста.
+func (s *Storage) SeededDatasets(ctx context.Context) (map[string]int, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT dataset, COUNT(*) FROM seed_user WHERE tenantId = $1 GROUP BY dataset`,
+		tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list datasets: %w", err)
+	}
+	defer rows.Close()
+
+	datasets := make(map[string]int)
+	for rows.Next() {
+		var name string
+		var n int
+		if err = rows.Scan(&name, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan dataset row: %w", err)
+		}
+		datasets[name] = n
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return datasets, nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// statement — именованный подготовленный запрос.
+type statement struct {
+	name string
+	sql  string
+}
+
+// Запросы SubscriptionStorage подготавливаются на каждом соединении при первом
+// использовании, а не при открытии соединения: на пустой базе таблиц ещё нет
+// до применения миграций.
+var (
+	stmtCreateSubs = statement{"subs_create", `
+        INSERT INTO subscription(serviceName, price, userID, startDate, endDate, tenantId)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING subscriptionId`}
+
+	stmtReadSubs = statement{"subs_read", `
+        SELECT serviceName, price, userID, startDate, endDate
+        FROM subscription
+        WHERE subscriptionId = $1 AND tenantId = $2`}
+
+	stmtUpdateSubs = statement{"subs_update", `
+        UPDATE subscription
+        SET serviceName = $1, price = $2, userID = $3, startDate = $4, endDate = $5
+        WHERE subscriptionId = $6 AND tenantId = $7`}
+
+	stmtDeleteSubs = statement{"subs_delete", `
+        DELETE FROM subscription WHERE subscriptionId = $1 AND tenantId = $2`}
+
+	stmtListSubs = statement{"subs_list", `
+        SELECT subscriptionId, serviceName, price, userID, startDate, endDate
+        FROM subscription
+        WHERE tenantId = $3
+          AND startDate < $1
+          AND ($2::uuid IS NULL OR userID = $2)
+        ORDER BY startDate DESC
+        LIMIT $4`}
+
+	// подписки сервиса, которые пользователь оплачивает и которые пересекаются с периодом расчёта
+	stmtPaidSubs = statement{"subs_paid", `
+        SELECT price, startDate, endDate
+        FROM subscription
+        WHERE tenantId = $5
+          AND userID = $1
+          AND serviceName = $2
+          AND startDate <= $4
+          AND (endDate IS NULL OR endDate >= $3)`}
+
+	// подписки сервиса, которые пользователь оплачивает или в которых участвует, с участниками
+	stmtSharedSubs = statement{"subs_shared", `
+        SELECT s.subscriptionId, s.price, s.userID, s.startDate, s.endDate,
+               m.userId, m.split, m.value
+        FROM subscription s
+        LEFT JOIN subscription_member m ON m.subscriptionId = s.subscriptionId
+        WHERE s.tenantId = $5
+          AND s.serviceName = $2
+          AND s.startDate <= $4
+          AND (s.endDate IS NULL OR s.endDate >= $3)
+          AND (s.userID = $1 OR EXISTS (
+              SELECT 1 FROM subscription_member sm
+              WHERE sm.subscriptionId = s.subscriptionId AND sm.userId = $1
+          ))`}
+)
+
+// withPrepared выполняет fn на соединении из pool, на котором подготовлены
+// запросы stmts. pgx помнит подготовленные запросы соединения, поэтому
+// повторная подготовка не обращается к базе.
+func withPrepared(ctx context.Context, pool *pgxpool.Pool, fn func(conn *pgx.Conn) error, stmts ...statement) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	for _, stmt := range stmts {
+		if _, err = conn.Conn().Prepare(ctx, stmt.name, stmt.sql); err != nil {
+			return fmt.Errorf("prepare %s: %w", stmt.name, err)
+		}
+	}
+	return fn(conn.Conn())
+}
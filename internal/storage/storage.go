@@ -1,58 +1,105 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	_ "github.com/jackc/pgx/v5/stdlib"
-	migrate "github.com/rubenv/sql-migrate"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"log/slog"
-	"net/url"
+	"sync/atomic"
 )
 
 type Storage struct {
-	lg *slog.Logger
+	lg   *slog.Logger
+	pool *pgxpool.Pool
+	// db — отдельные соединения database/sql для sql-migrate, видящие все арендаторы.
 	db *sql.DB
+	// replicas — реплики для чтения, next выбирает их по кругу.
+	replicas []*replica
+	next     atomic.Uint64
+	// migrated запоминает, что все встроенные миграции применены.
+	migrated atomic.Bool
 }
 
-func New(
-	lg *slog.Logger,
-	username string,
-	password string,
-	address string,
-	database string,
-) (*Storage, error) {
+func New(lg *slog.Logger, cfg Config) (*Storage, error) {
 	lg = lg.With("module", "storage")
-	lg.Info("initializing database connection", "user", username, "host", address, "db", database)
+	lg.Info("initializing database connection",
+		"user", cfg.User,
+		"host", cfg.Address,
+		"db", cfg.DbName,
+		"sslmode", cfg.SSLMode,
+	)
 
-	dsn := (&url.URL{
-		Scheme: "postgresql",
-		User:   url.UserPassword(username, password),
-		Host:   address,
-		Path:   database,
-	}).String()
-
-	sqlDB, err := sql.Open("pgx", dsn)
+	pool, err := openPool(cfg.DSN(), cfg.Pool)
 	if err != nil {
-		lg.Error("failed to open database connection", "err", err, "dsn", dsn)
+		lg.Error("failed to open database connection", "err", err)
 		return nil, fmt.Errorf("init db: %v", err)
 	}
-	lg.Info("database connection opened successfully")
+	lg.Info("database connection opened successfully",
+		"max_conns", pool.Config().MaxConns,
+		"min_conns", pool.Config().MinConns,
+	)
 
-	if err = sqlDB.Ping(); err != nil {
+	if err = pool.Ping(context.Background()); err != nil {
 		lg.Error("failed to ping database", "err", err)
+		pool.Close()
 		return nil, fmt.Errorf("ping db: %v", err)
 	}
 	lg.Info("database ping successful")
 
-	return &Storage{
-		lg: lg,
-		db: sqlDB,
-	}, nil
+	s := &Storage{
+		lg:   lg,
+		pool: pool,
+		db:   stdlib.OpenDB(*pool.Config().ConnConfig.Copy(), stdlib.OptionAfterConnect(allTenantsSession)),
+	}
+	for _, dsn := range cfg.Replicas {
+		r, err := openReplica(lg, dsn, cfg.Pool)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.replicas = append(s.replicas, r)
+	}
+	return s, nil
+}
+
+// openPool создаёт пул по строке подключения dsn с настройками cfg.
+// Соединения открываются лениво, поэтому доступность базы проверяет вызывающий.
+func openPool(dsn string, cfg PoolConfig) (*pgxpool.Pool, error) {
+	poolCfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	if cfg.MaxConns > 0 {
+		poolCfg.MaxConns = cfg.MaxConns
+	}
+	poolCfg.MinConns = cfg.MinConns
+	if cfg.ConnMaxLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.ConnMaxLifetime
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.ConnMaxIdleTime
+	}
+	poolCfg.ConnConfig.Tracer = queryTracer{}
+	sessions := &tenantSessions{}
+	poolCfg.BeforeAcquire = sessions.beforeAcquire
+	poolCfg.BeforeClose = sessions.beforeClose
+	return pgxpool.NewWithConfig(context.Background(), poolCfg)
+}
+
+// Pool возвращает пул соединений, например для сбора его статистики.
+func (s *Storage) Pool() *pgxpool.Pool {
+	return s.pool
 }
 
 func (s *Storage) Close() error {
 	s.lg.Info("closing database connection")
 	err := s.db.Close()
+	s.pool.Close()
+	for _, r := range s.replicas {
+		r.pool.Close()
+	}
 	if err != nil {
 		s.lg.Error("error closing database connection", "err", err)
 		return err
@@ -61,19 +108,6 @@ func (s *Storage) Close() error {
 	return nil
 }
 
-func (s *Storage) Migrate(direction migrate.MigrationDirection) error {
-	s.lg.Info("starting database migration", "direction", direction)
-
-	migrations := &migrate.FileMigrationSource{
-		Dir: "/root/migrate",
-	}
-
-	n, err := migrate.Exec(s.db, "postgres", migrations, direction)
-	if err != nil {
-		s.lg.Error("database migration failed", "err", err)
-		return fmt.Errorf("error for migrate: %v", err)
-	}
-
-	s.lg.Info("database migration completed successfully", "migrations_applied", n)
-	return nil
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
 }
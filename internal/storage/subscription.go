@@ -2,11 +2,14 @@ package storage
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"time"
 )
 
@@ -15,41 +18,43 @@ type SubscriptionStorage interface {
 	ReadSubs(ctx context.Context, subsID uuid.UUID) (*entity.Subscription, error)
 	UpdateSubs(ctx context.Context, subsID uuid.UUID, subs *entity.Subscription) error
 	DeleteSubs(ctx context.Context, subsID uuid.UUID) error
-	ListSubs(ctx context.Context, time time.Time) ([]entity.Subscription, error)
-	TotalCost(ctx context.Context, t entity.TotalCost) (int, error)
+	ListSubs(ctx context.Context, time time.Time, userID uuid.UUID) ([]entity.Subscription, error)
+	TotalCost(ctx context.Context, t entity.TotalCost) (entity.CostReport, error)
 }
 
 var ErrNotFound = errors.New("subscription not found")
 
 func (s *Storage) CreateSubs(ctx context.Context, subs *entity.Subscription) (uuid.UUID, error) {
-	lg := s.lg.With("module", "storage", "method", "CreateSubs")
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "CreateSubs")
 
 	endDateStr := "nil"
 	if subs.EndDate != nil {
-		endDateStr = subs.EndDate.Format("2006-01")
+		endDateStr = subs.EndDate.Format(entity.DateLayout)
 	}
 
 	lg.Info("creating subscription in database",
 		"user_id", subs.UserId,
 		"service_name", subs.ServiceName,
 		"price", subs.Price,
-		"start_date", subs.StartDate.Format("2006-01"),
+		"start_date", subs.StartDate.Format(entity.DateLayout),
 		"end_date", endDateStr,
 	)
 
-	end := interface{}(nil)
-	if subs.EndDate != nil {
-		end = *subs.EndDate
-	}
-
-	err := s.db.QueryRowContext(ctx,
-		`INSERT INTO subscription(serviceName, price, userID, startDate, endDate)
-		 VALUES ($1, $2, $3, $4, $5)
-		 RETURNING subscriptionId`,
-		subs.ServiceName, subs.Price, subs.UserId, subs.StartDate, end,
-	).Scan(&subs.SubsID)
+	err := withPrepared(ctx, s.pool, func(conn *pgx.Conn) error {
+		return conn.QueryRow(ctx, stmtCreateSubs.name,
+			subs.ServiceName, subs.Price, subs.UserId, subs.StartDate, subs.EndDate, tenant.FromContext(ctx),
+		).Scan(&subs.SubsID)
+	}, stmtCreateSubs)
 
 	if err != nil {
+		if pgErrorCode(err) == pgForeignKeyViolation {
+			lg.Info("subscription references unknown user", "user_id", subs.UserId)
+			return uuid.Nil, ErrUserNotFound
+		}
+		if cerr := asConstraintError(err); cerr != nil {
+			lg.Info("constraint violated", "err", cerr)
+			return uuid.Nil, cerr
+		}
 		lg.Error("failed to create subscription in database", "err", err)
 		return uuid.Nil, fmt.Errorf("create subscription: %w", err)
 	}
@@ -59,22 +64,18 @@ func (s *Storage) CreateSubs(ctx context.Context, subs *entity.Subscription) (uu
 }
 
 func (s *Storage) ReadSubs(ctx context.Context, subsID uuid.UUID) (*entity.Subscription, error) {
-	lg := s.lg.With("module", "storage", "method", "ReadSubs")
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "ReadSubs")
 	lg.Info("reading subscription from database", "subscription_id", subsID)
 
 	var subs entity.Subscription
-	var startDate time.Time
-	var endDate sql.NullTime
-
-	err := s.db.QueryRowContext(ctx,
-		`SELECT serviceName, price, userID, startDate, endDate
-		 FROM subscription
-		 WHERE subscriptionId = $1`, subsID).
-		Scan(&subs.ServiceName, &subs.Price, &subs.UserId, &startDate, &endDate)
+	err := s.withReadPrepared(ctx, func(conn *pgx.Conn) error {
+		return conn.QueryRow(ctx, stmtReadSubs.name, subsID, tenant.FromContext(ctx)).
+			Scan(&subs.ServiceName, &subs.Price, &subs.UserId, &subs.StartDate, &subs.EndDate)
+	}, stmtReadSubs)
 	subs.SubsID = subsID
 
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			lg.Info("subscription not found", "subscription_id", subsID)
 			return nil, ErrNotFound
 		}
@@ -82,17 +83,10 @@ func (s *Storage) ReadSubs(ctx context.Context, subsID uuid.UUID) (*entity.Subsc
 		return nil, fmt.Errorf("query subscription: %w", err)
 	}
 
-	subs.StartDate = startDate
-	if endDate.Valid {
-		subs.EndDate = &endDate.Time
-	} else {
-		subs.EndDate = nil
-	}
-
-	startDateStr := subs.StartDate.Format("2006-01")
+	startDateStr := subs.StartDate.Format(entity.DateLayout)
 	endDateStr := "nil"
 	if subs.EndDate != nil {
-		endDateStr = subs.EndDate.Format("2006-01")
+		endDateStr = subs.EndDate.Format(entity.DateLayout)
 	}
 
 	lg.Info("subscription retrieved successfully",
@@ -108,11 +102,11 @@ func (s *Storage) ReadSubs(ctx context.Context, subsID uuid.UUID) (*entity.Subsc
 }
 
 func (s *Storage) UpdateSubs(ctx context.Context, subsID uuid.UUID, subs *entity.Subscription) error {
-	lg := s.lg.With("module", "storage", "method", "UpdateSubs")
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "UpdateSubs")
 
 	endDateStr := "nil"
 	if subs.EndDate != nil {
-		endDateStr = subs.EndDate.Format("2006-01")
+		endDateStr = subs.EndDate.Format(entity.DateLayout)
 	}
 
 	lg.Info("updating subscription in database",
@@ -120,40 +114,39 @@ func (s *Storage) UpdateSubs(ctx context.Context, subsID uuid.UUID, subs *entity
 		"user_id", subs.UserId,
 		"service_name", subs.ServiceName,
 		"price", subs.Price,
-		"start_date", subs.StartDate.Format("2006-01"),
+		"start_date", subs.StartDate.Format(entity.DateLayout),
 		"end_date", endDateStr,
 	)
 
-	var end interface{}
-	if subs.EndDate != nil {
-		end = *subs.EndDate
-	} else {
-		end = nil
-	}
-
-	r, err := s.db.ExecContext(ctx, `UPDATE subscription
-	SET serviceName=$1, price=$2, userID=$3, startDate=$4, endDate=$5
-	WHERE subscriptionId=$6`,
-		subs.ServiceName,
-		subs.Price,
-		subs.UserId,
-		subs.StartDate,
-		end,
-		subsID,
-	)
+	var r pgconn.CommandTag
+	err := withPrepared(ctx, s.pool, func(conn *pgx.Conn) error {
+		var err error
+		r, err = conn.Exec(ctx, stmtUpdateSubs.name,
+			subs.ServiceName,
+			subs.Price,
+			subs.UserId,
+			subs.StartDate,
+			subs.EndDate,
+			subsID,
+			tenant.FromContext(ctx),
+		)
+		return err
+	}, stmtUpdateSubs)
 
 	if err != nil {
+		if pgErrorCode(err) == pgForeignKeyViolation {
+			lg.Info("subscription references unknown user", "user_id", subs.UserId)
+			return ErrUserNotFound
+		}
+		if cerr := asConstraintError(err); cerr != nil {
+			lg.Info("constraint violated", "err", cerr)
+			return cerr
+		}
 		lg.Error("failed to execute update query", "err", err)
 		return fmt.Errorf("update subscription: %w", err)
 	}
 
-	rows, err := r.RowsAffected()
-	if err != nil {
-		lg.Error("failed to get rows affected", "err", err)
-		return fmt.Errorf("rows affected: %w", err)
-	}
-
-	if rows == 0 {
+	if r.RowsAffected() == 0 {
 		lg.Info("no subscription updated, not found", "subscription_id", subsID)
 		return ErrNotFound
 	}
@@ -163,22 +156,21 @@ func (s *Storage) UpdateSubs(ctx context.Context, subsID uuid.UUID, subs *entity
 }
 
 func (s *Storage) DeleteSubs(ctx context.Context, subsID uuid.UUID) error {
-	lg := s.lg.With("module", "storage", "method", "DeleteSubs")
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "DeleteSubs")
 	lg.Info("deleting subscription from database", "subscription_id", subsID)
 
-	r, err := s.db.ExecContext(ctx, `DELETE FROM subscription WHERE subscriptionId = $1`, subsID)
+	var r pgconn.CommandTag
+	err := withPrepared(ctx, s.pool, func(conn *pgx.Conn) error {
+		var err error
+		r, err = conn.Exec(ctx, stmtDeleteSubs.name, subsID, tenant.FromContext(ctx))
+		return err
+	}, stmtDeleteSubs)
 	if err != nil {
 		lg.Error("failed to execute delete query", "subscription_id", subsID, "err", err)
 		return fmt.Errorf("deleting a subscription: %w", err)
 	}
 
-	rows, err := r.RowsAffected()
-	if err != nil {
-		lg.Error("failed to get rows affected", "subscription_id", subsID, "err", err)
-		return fmt.Errorf("checking rows affected: %w", err)
-	}
-
-	if rows == 0 {
+	if r.RowsAffected() == 0 {
 		lg.Info("no subscription deleted, not found", "subscription_id", subsID)
 		return ErrNotFound
 	}
@@ -187,112 +179,129 @@ func (s *Storage) DeleteSubs(ctx context.Context, subsID uuid.UUID) error {
 	return nil
 }
 
-func (s *Storage) ListSubs(ctx context.Context, pointOfReference time.Time) ([]entity.Subscription, error) {
-	subs := make([]entity.Subscription, 0, 10)
-
-	rows, err := s.db.QueryContext(ctx, `
-        SELECT
-            subscriptionId,
-            serviceName,
-            price,
-            userID,
-            startDate,
-            endDate
-        FROM subscription
-        WHERE startDate < $1
-        ORDER BY startDate DESC
-        LIMIT 10
-    `, pointOfReference)
+// ListSubs возвращает до ListLimit (по умолчанию 10) подписок арендатора, начавшихся раньше pointOfReference.
+// Если userID не равен uuid.Nil, выборка ограничивается подписками этого пользователя.
+func (s *Storage) ListSubs(ctx context.Context, pointOfReference time.Time, userID uuid.UUID) ([]entity.Subscription, error) {
+	limit := tenant.SettingsFromContext(ctx).ListLimit
+	var subs []entity.Subscription
+
+	err := s.withReadPrepared(ctx, func(conn *pgx.Conn) error {
+		rows, err := conn.Query(ctx, stmtListSubs.name,
+			pointOfReference,
+			uuid.NullUUID{UUID: userID, Valid: userID != uuid.Nil},
+			tenant.FromContext(ctx),
+			limit,
+		)
+		if err != nil {
+			return err
+		}
+		subs, err = collectSubs(rows, make([]entity.Subscription, 0, limit))
+		return err
+	}, stmtListSubs)
 	if err != nil {
 		return nil, fmt.Errorf("listing subscriptions: %w", err)
 	}
+
+	return subs, nil
+}
+
+// collectSubs дописывает в subs подписки из строк subscriptionId, serviceName,
+// price, userID, startDate, endDate и закрывает rows.
+func collectSubs(rows pgx.Rows, subs []entity.Subscription) ([]entity.Subscription, error) {
 	defer rows.Close()
 
 	for rows.Next() {
 		var sub entity.Subscription
-		var end sql.NullTime // используем NullTime для nullable поля
-
-		err = rows.Scan(
+		err := rows.Scan(
 			&sub.SubsID,
 			&sub.ServiceName,
 			&sub.Price,
 			&sub.UserId,
 			&sub.StartDate,
-			&end,
+			&sub.EndDate,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan subscription row: %w", err)
 		}
-
-		// Конвертируем NullTime в *time.Time
-		if end.Valid {
-			sub.EndDate = &end.Time
-		} else {
-			sub.EndDate = nil
-		}
-
 		subs = append(subs, sub)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("rows iteration error: %w", err)
 	}
-
 	return subs, nil
 }
 
-func (s *Storage) TotalCost(ctx context.Context, t entity.TotalCost) (int, error) {
-	lg := s.lg.With("module", "storage", "method", "TotalCost")
+func (s *Storage) TotalCost(ctx context.Context, t entity.TotalCost) (entity.CostReport, error) {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "TotalCost")
 	lg.Info("calculating total cost for user",
 		"user_id", t.UserId,
 		"service_name", t.ServiceName,
-		"date1", t.Date1.Format("2006-01"),
-		"date2", t.Date2.Format("2006-01"),
+		"date1", t.Date1.Format(entity.DateLayout),
+		"date2", t.Date2.Format(entity.DateLayout),
+		"proration", t.Proration,
 	)
 
-	var sum int
-	err := s.db.QueryRowContext(ctx, `
-	WITH months AS (
-	    SELECT generate_series(
-	        $3::date, 
-	        $4::date, 
-	        interval '1 month'
-	    )::date AS month_start
-	),
-	active_subs AS (
-	    SELECT serviceName, price, startDate, endDate
-	    FROM subscription
-	    WHERE userID = $1
-	      AND serviceName = $2
-	      AND startDate <= $4
-	      AND (endDate IS NULL OR endDate >= $3)
-	),
-	month_subs AS (
-	    SELECT m.month_start, a.price
-	    FROM months m
-	    JOIN active_subs a 
-	      ON m.month_start >= a.startDate
-	     AND (a.endDate IS NULL OR m.month_start <= a.endDate)
-	),
-	unique_months AS (
-	    SELECT month_start, MAX(price) AS price
-	    FROM month_subs
-	    GROUP BY month_start
-	)
-	SELECT COALESCE(SUM(price), 0) AS total_cost
-	FROM unique_months;
-	`, t.UserId, t.ServiceName, t.Date1, t.Date2).Scan(&sum)
+	// обе выборки уходят в базу одним пакетом, за один сетевой обмен
+	var items []entity.CostItem
+	var share int
+	err := s.withReadPrepared(ctx, func(conn *pgx.Conn) error {
+		args := []any{t.UserId, t.ServiceName, t.Date1, t.Date2, tenant.FromContext(ctx)}
+		batch := &pgx.Batch{}
+		batch.Queue(stmtPaidSubs.name, args...)
+		batch.Queue(stmtSharedSubs.name, args...)
 
+		results := conn.SendBatch(ctx, batch)
+		defer results.Close()
+
+		rows, err := results.Query()
+		if err != nil {
+			return fmt.Errorf("getting total cost: %w", err)
+		}
+		if items, err = paidSubs(rows); err != nil {
+			return fmt.Errorf("getting total cost: %w", err)
+		}
+
+		rows, err = results.Query()
+		if err != nil {
+			return fmt.Errorf("getting fair share: %w", err)
+		}
+		if share, err = fairShare(rows, t); err != nil {
+			return fmt.Errorf("getting fair share: %w", err)
+		}
+		return nil
+	}, stmtPaidSubs, stmtSharedSubs)
 	if err != nil {
 		lg.Error("failed to calculate total cost", "err", err)
-		return 0, fmt.Errorf("getting total cost: %w", err)
+		return entity.CostReport{}, err
 	}
+	sum := entity.Cost(items, t.Date1, t.Date2, t.Proration)
 
 	lg.Info("total cost calculated successfully",
 		"user_id", t.UserId,
 		"service_name", t.ServiceName,
 		"total_cost", sum,
+		"fair_share", share,
 	)
 
-	return sum, nil
+	return entity.CostReport{PaidBy: sum, FairShare: share, Proration: t.Proration}, nil
+}
+
+// paidSubs читает результат stmtPaidSubs.
+func paidSubs(rows pgx.Rows) ([]entity.CostItem, error) {
+	defer rows.Close()
+
+	items := make([]entity.CostItem, 0)
+	for rows.Next() {
+		var item entity.CostItem
+		if err := rows.Scan(&item.Price, &item.StartDate, &item.EndDate); err != nil {
+			return nil, fmt.Errorf("failed to scan paid subscription row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return items, nil
 }
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/logger"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type UserStorage interface {
+	CreateUser(ctx context.Context, user *entity.User) (uuid.UUID, error)
+	ReadUser(ctx context.Context, userID uuid.UUID) (*entity.User, error)
+	UpdateUser(ctx context.Context, userID uuid.UUID, user *entity.User) error
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+	ListUsers(ctx context.Context) ([]entity.User, error)
+	ListUserSubs(ctx context.Context, userID uuid.UUID) ([]entity.Subscription, error)
+}
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailTaken   = errors.New("email already in use")
+)
+
+func (s *Storage) CreateUser(ctx context.Context, user *entity.User) (uuid.UUID, error) {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "CreateUser")
+	lg.Info("creating user in database", "email", user.Email)
+
+	err := s.pool.QueryRow(ctx,
+		`INSERT INTO users(name, email, timezone, currency, tenantId)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING userId, createdAt`,
+		user.Name, user.Email, user.Timezone, user.Currency, tenant.FromContext(ctx),
+	).Scan(&user.UserId, &user.CreatedAt)
+
+	if err != nil {
+		if pgErrorCode(err) == pgUniqueViolation {
+			lg.Info("email already in use", "email", user.Email)
+			return uuid.Nil, ErrEmailTaken
+		}
+		if cerr := asConstraintError(err); cerr != nil {
+			lg.Info("constraint violated", "err", cerr)
+			return uuid.Nil, cerr
+		}
+		lg.Error("failed to create user in database", "err", err)
+		return uuid.Nil, fmt.Errorf("create user: %w", err)
+	}
+
+	lg.Info("user created successfully", "user_id", user.UserId)
+	return user.UserId, nil
+}
+
+func (s *Storage) ReadUser(ctx context.Context, userID uuid.UUID) (*entity.User, error) {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "ReadUser")
+	lg.Info("reading user from database", "user_id", userID)
+
+	var user entity.User
+	err := s.pool.QueryRow(ctx,
+		`SELECT userId, name, email, timezone, currency, createdAt
+		 FROM users
+		 WHERE userId = $1 AND tenantId = $2`, userID, tenant.FromContext(ctx)).
+		Scan(&user.UserId, &user.Name, &user.Email, &user.Timezone, &user.Currency, &user.CreatedAt)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			lg.Info("user not found", "user_id", userID)
+			return nil, ErrUserNotFound
+		}
+		lg.Error("failed to query user", "user_id", userID, "err", err)
+		return nil, fmt.Errorf("query user: %w", err)
+	}
+
+	lg.Info("user retrieved successfully", "user_id", userID)
+	return &user, nil
+}
+
+func (s *Storage) UpdateUser(ctx context.Context, userID uuid.UUID, user *entity.User) error {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "UpdateUser")
+	lg.Info("updating user in database", "user_id", userID, "email", user.Email)
+
+	r, err := s.pool.Exec(ctx, `UPDATE users
+	SET name=$1, email=$2, timezone=$3, currency=$4
+	WHERE userId=$5 AND tenantId=$6`,
+		user.Name,
+		user.Email,
+		user.Timezone,
+		user.Currency,
+		userID,
+		tenant.FromContext(ctx),
+	)
+	if err != nil {
+		if pgErrorCode(err) == pgUniqueViolation {
+			lg.Info("email already in use", "email", user.Email)
+			return ErrEmailTaken
+		}
+		if cerr := asConstraintError(err); cerr != nil {
+			lg.Info("constraint violated", "err", cerr)
+			return cerr
+		}
+		lg.Error("failed to execute update query", "err", err)
+		return fmt.Errorf("update user: %w", err)
+	}
+
+	if r.RowsAffected() == 0 {
+		lg.Info("no user updated, not found", "user_id", userID)
+		return ErrUserNotFound
+	}
+
+	lg.Info("user updated successfully", "user_id", userID)
+	return nil
+}
+
+func (s *Storage) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "DeleteUser")
+	lg.Info("deleting user from database", "user_id", userID)
+
+	r, err := s.pool.Exec(ctx, `DELETE FROM users WHERE userId = $1 AND tenantId = $2`,
+		userID, tenant.FromContext(ctx))
+	if err != nil {
+		lg.Error("failed to execute delete query", "user_id", userID, "err", err)
+		return fmt.Errorf("deleting a user: %w", err)
+	}
+
+	if r.RowsAffected() == 0 {
+		lg.Info("no user deleted, not found", "user_id", userID)
+		return ErrUserNotFound
+	}
+
+	lg.Info("user deleted successfully", "user_id", userID)
+	return nil
+}
+
+func (s *Storage) ListUsers(ctx context.Context) ([]entity.User, error) {
+	users := make([]entity.User, 0)
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT userId, name, email, timezone, currency, createdAt
+        FROM users
+        WHERE tenantId = $1
+        ORDER BY createdAt
+    `, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("listing users: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user entity.User
+		err = rows.Scan(&user.UserId, &user.Name, &user.Email, &user.Timezone, &user.Currency, &user.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user row: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return users, nil
+}
+
+func (s *Storage) ListUserSubs(ctx context.Context, userID uuid.UUID) ([]entity.Subscription, error) {
+	lg := logger.WithTrace(ctx, s.lg).With("module", "storage", "method", "ListUserSubs")
+	lg.Info("listing user subscriptions", "user_id", userID)
+
+	if _, err := s.ReadUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	subs := make([]entity.Subscription, 0)
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT
+            subscriptionId,
+            serviceName,
+            price,
+            userID,
+            startDate,
+            endDate
+        FROM subscription
+        WHERE userID = $1 AND tenantId = $2
+        ORDER BY startDate DESC
+    `, userID, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("listing user subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sub entity.Subscription
+		err = rows.Scan(
+			&sub.SubsID,
+			&sub.ServiceName,
+			&sub.Price,
+			&sub.UserId,
+			&sub.StartDate,
+			&sub.EndDate,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription row: %w", err)
+		}
+
+		subs = append(subs, sub)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	lg.Info("user subscriptions retrieved successfully", "user_id", userID, "count", len(subs))
+	return subs, nil
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Watch раз в interval проверяет соединение с базой и репликами до отмены ctx.
+// Потеря и восстановление соединения пишутся в лог, а report (если задан)
+// получает результат каждой проверки основной базы. Переподключение выполняет
+// сам пул pgxpool; реплика, ответившая на проверку, снова принимает чтения.
+func (s *Storage) Watch(ctx context.Context, interval time.Duration, report func(up bool)) {
+	lg := s.lg.With("method", "Watch")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	up := true
+	var downSince time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, interval)
+		err := s.pool.Ping(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case err != nil && up:
+			up = false
+			downSince = time.Now()
+			lg.Error("database connection lost", "err", err)
+		case err != nil:
+			lg.Warn("database is still unavailable", "down_for", time.Since(downSince).Round(time.Second), "err", err)
+		case !up:
+			up = true
+			lg.Info("database connection restored", "downtime", time.Since(downSince).Round(time.Millisecond))
+		}
+		if report != nil {
+			report(up)
+		}
+
+		s.checkReplicas(ctx, lg, interval)
+	}
+}
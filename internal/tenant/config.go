@@ -0,0 +1,41 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Config struct {
+	Enabled bool                `yaml:"enabled"`
+	Header  string              `yaml:"header"`
+	Tenants map[string]Settings `yaml:"tenants"`
+}
+
+// Settings — настройки, которые можно переопределить для отдельного арендатора.
+type Settings struct {
+	Name            string `yaml:"name"`
+	DefaultCurrency string `yaml:"default_currency"`
+	ListLimit       int    `yaml:"list_limit"`
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.Header == "" {
+		errs = append(errs, errors.New("header is required"))
+	}
+	if _, ok := c.Tenants[Default]; c.Enabled && !ok {
+		errs = append(errs, fmt.Errorf("tenants.%s is required", Default))
+	}
+	for id, settings := range c.Tenants {
+		if len(id) > 64 {
+			errs = append(errs, fmt.Errorf("tenants.%s: id must be at most 64 characters", id))
+		}
+		if settings.ListLimit < 0 {
+			errs = append(errs, fmt.Errorf("tenants.%s.list_limit must not be negative", id))
+		}
+		if cur := settings.DefaultCurrency; cur != "" && len(cur) != 3 {
+			errs = append(errs, fmt.Errorf("tenants.%s.default_currency must be a 3-letter ISO 4217 code", id))
+		}
+	}
+	return errors.Join(errs...)
+}
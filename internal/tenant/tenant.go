@@ -0,0 +1,104 @@
+package tenant
+
+import (
+	"context"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/auth"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/problem"
+	"log/slog"
+	"net/http"
+)
+
+const (
+	Default          = "default"
+	DefaultHeader    = "X-Tenant-ID"
+	DefaultListLimit = 10
+)
+
+type ctxKey struct{}
+
+type current struct {
+	id       string
+	settings Settings
+}
+
+func WithTenant(ctx context.Context, id string, settings Settings) context.Context {
+	return context.WithValue(ctx, ctxKey{}, current{id: id, settings: settings})
+}
+
+// FromContext возвращает арендатора запроса, без него — арендатора по умолчанию.
+func FromContext(ctx context.Context) string {
+	if c, ok := ctx.Value(ctxKey{}).(current); ok && c.id != "" {
+		return c.id
+	}
+	return Default
+}
+
+// Lookup возвращает арендатора, явно выставленного в контексте, без подстановки
+// арендатора по умолчанию.
+func Lookup(ctx context.Context) (string, bool) {
+	c, ok := ctx.Value(ctxKey{}).(current)
+	return c.id, ok && c.id != ""
+}
+
+// SettingsFromContext возвращает настройки арендатора с подставленными значениями по умолчанию.
+func SettingsFromContext(ctx context.Context) Settings {
+	c, _ := ctx.Value(ctxKey{}).(current)
+	settings := c.settings
+	if settings.ListLimit <= 0 {
+		settings.ListLimit = DefaultListLimit
+	}
+	return settings
+}
+
+// Middleware определяет арендатора запроса. Для аутентифицированного субъекта
+// арендатор берётся из учётных данных (без него — арендатор по умолчанию), и
+// заголовок может только совпадать с ним; выбирать арендатора заголовком могут
+// субъекты с разрешением tenant:any и запросы при отключённой аутентификации.
+func Middleware(log *slog.Logger, cfg Config) func(http.Handler) http.Handler {
+	lg := log.With("module", "tenant")
+	header := cfg.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !cfg.Enabled {
+				next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), Default, cfg.Tenants[Default])))
+				return
+			}
+
+			requested := r.Header.Get(header)
+			id := requested
+			if identity, ok := auth.FromContext(r.Context()); ok {
+				switch {
+				case identity.Tenant != "":
+					id = identity.Tenant
+				case identity.Can(auth.PermAnyTenant):
+					// оператор платформы выбирает арендатора заголовком
+				default:
+					// учётные данные без арендатора относятся к арендатору по умолчанию
+					id = Default
+				}
+				if requested != "" && requested != id {
+					lg.Warn("tenant header does not match credentials",
+						"header", requested, "tenant", id, "subject", identity.Subject)
+					problem.Write(w, r, problem.New(http.StatusForbidden, problem.CodeTenantMismatch, "tenant does not match credentials"))
+					return
+				}
+			}
+			if id == "" {
+				id = Default
+			}
+
+			settings, ok := cfg.Tenants[id]
+			if !ok {
+				lg.Warn("unknown tenant", "tenant", id)
+				problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeUnknownTenant, "unknown tenant "+id))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), id, settings)))
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+	// Exporter — "otlp" (OTLP/HTTP) или "stdout" для локальной отладки.
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	var errs []error
+	switch c.Exporter {
+	case "otlp", "stdout", "":
+	default:
+		errs = append(errs, fmt.Errorf("exporter must be otlp or stdout, got %q", c.Exporter))
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		errs = append(errs, errors.New("sample_ratio must be between 0 and 1"))
+	}
+	return errors.Join(errs...)
+}
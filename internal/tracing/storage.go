@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/entity"
+	"github.com/AndreySirin/-Effective-Mobile-/internal/storage"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// subscriptionStorage — декоратор storage.SubscriptionStorage, открывающий
+// спан на каждый вызов метода хранилища.
+type subscriptionStorage struct {
+	next storage.SubscriptionStorage
+}
+
+func InstrumentStorage(next storage.SubscriptionStorage) storage.SubscriptionStorage {
+	return &subscriptionStorage{next: next}
+}
+
+func start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, "storage."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+func finish(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s *subscriptionStorage) CreateSubs(ctx context.Context, subs *entity.Subscription) (id uuid.UUID, err error) {
+	ctx, span := start(ctx, "CreateSubs", attribute.String("user_id", subs.UserId.String()))
+	defer func() { finish(span, err) }()
+	return s.next.CreateSubs(ctx, subs)
+}
+
+func (s *subscriptionStorage) ReadSubs(ctx context.Context, subsID uuid.UUID) (subs *entity.Subscription, err error) {
+	ctx, span := start(ctx, "ReadSubs", attribute.String("subscription_id", subsID.String()))
+	defer func() { finish(span, err) }()
+	return s.next.ReadSubs(ctx, subsID)
+}
+
+func (s *subscriptionStorage) UpdateSubs(ctx context.Context, subsID uuid.UUID, subs *entity.Subscription) (err error) {
+	ctx, span := start(ctx, "UpdateSubs", attribute.String("subscription_id", subsID.String()))
+	defer func() { finish(span, err) }()
+	return s.next.UpdateSubs(ctx, subsID, subs)
+}
+
+func (s *subscriptionStorage) DeleteSubs(ctx context.Context, subsID uuid.UUID) (err error) {
+	ctx, span := start(ctx, "DeleteSubs", attribute.String("subscription_id", subsID.String()))
+	defer func() { finish(span, err) }()
+	return s.next.DeleteSubs(ctx, subsID)
+}
+
+func (s *subscriptionStorage) ListSubs(ctx context.Context, t time.Time, userID uuid.UUID) (subs []entity.Subscription, err error) {
+	ctx, span := start(ctx, "ListSubs", attribute.String("point_of_reference", t.Format(time.DateOnly)))
+	defer func() { finish(span, err) }()
+	return s.next.ListSubs(ctx, t, userID)
+}
+
+func (s *subscriptionStorage) TotalCost(ctx context.Context, t entity.TotalCost) (report entity.CostReport, err error) {
+	ctx, span := start(ctx, "TotalCost",
+		attribute.String("user_id", t.UserId.String()),
+		attribute.String("service_name", t.ServiceName),
+		attribute.String("proration", string(t.Proration)),
+	)
+	defer func() { finish(span, err) }()
+	return s.next.TotalCost(ctx, t)
+}